@@ -0,0 +1,84 @@
+package client
+
+import "github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+
+// DefaultAuthHeader is the header the OTP service expects the secret in.
+const DefaultAuthHeader = "X-Secret"
+
+// HttpRequestWithHeaders is a request that an Authenticator can attach
+// headers to.
+type HttpRequestWithHeaders = http_client.HttpRequestWithHeaders
+
+// Authenticator attaches the client's secret to every request. Headers set by
+// an Authenticator are stripped from redirects to other hosts, and from those
+// downgrading https to http.
+type Authenticator interface {
+	Apply(req HttpRequestWithHeaders, secret string)
+}
+
+// HeaderAuthenticator sends the secret as is in the named header.
+type HeaderAuthenticator string
+
+func (h HeaderAuthenticator) Apply(req HttpRequestWithHeaders, secret string) {
+	req.SetHeader(string(h), secret)
+}
+
+// BearerAuthenticator sends the secret as a bearer token in the Authorization
+// header, e.g. for gateways which strip X- prefixed headers.
+type BearerAuthenticator struct{}
+
+func (BearerAuthenticator) Apply(req HttpRequestWithHeaders, secret string) {
+	req.SetHeader("Authorization", "Bearer "+secret)
+}
+
+// WithAuthenticator attaches the secret to requests with authenticator instead
+// of sending it in the X-Secret header.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(oc *OtpClient) {
+		if authenticator == nil {
+			oc.setConfigErr("authenticator is missing")
+			return
+		}
+
+		oc.authenticator = authenticator
+	}
+}
+
+// WithAuthHeader sends the secret in the named header instead of X-Secret.
+func WithAuthHeader(name string) Option {
+	return func(oc *OtpClient) {
+		if name == "" {
+			oc.setConfigErr("auth header name must not be empty")
+			return
+		}
+
+		oc.authenticator = HeaderAuthenticator(name)
+	}
+}
+
+// auth returns the client's Authenticator, defaulting to the X-Secret header
+// for clients which were not created by New.
+func (oc *OtpClient) auth() Authenticator {
+	if oc.authenticator == nil {
+		return HeaderAuthenticator(DefaultAuthHeader)
+	}
+
+	return oc.authenticator
+}
+
+// recordingHeaders records the names of the headers an Authenticator sets, so
+// that they can be treated as sensitive.
+type recordingHeaders struct {
+	request *http_client.HttpRequest
+	names   []string
+}
+
+func (r *recordingHeaders) AddHeader(key, value string) {
+	r.request.AddHeader(key, value)
+	r.names = append(r.names, key)
+}
+
+func (r *recordingHeaders) SetHeader(key, value string) {
+	r.request.SetHeader(key, value)
+	r.names = append(r.names, key)
+}
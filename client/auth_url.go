@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOtpAlgorithm = "SHA1"
+	defaultOtpDigits    = 6
+)
+
+// OtpParameters are the parameters encoded in an otpauth:// provisioning url.
+type OtpParameters struct {
+	// Type is either "totp" or "hotp".
+	Type      string
+	Issuer    string
+	Account   string
+	Secret    string
+	Algorithm string
+	Digits    int
+	Period    time.Duration
+}
+
+// AuthUrlInfo is the information encoded in an otpauth:// provisioning url.
+type AuthUrlInfo = OtpParameters
+
+// ParseAuthUrl parses an otpauth:// provisioning url, such as the AuthUrl of
+// CreateUserOtpResponse. The issuer and account are taken from the label when
+// absent from the query, and missing optional parameters take their defaults
+// of SHA1, 6 digits and a 30 second period.
+func ParseAuthUrl(authUrl string) (AuthUrlInfo, error) {
+	u, err := url.Parse(authUrl)
+	if err != nil {
+		return OtpParameters{}, fmt.Errorf("invalid auth url: %w", err)
+	}
+
+	if u.Scheme != "otpauth" {
+		return OtpParameters{}, fmt.Errorf("invalid auth url: unexpected scheme %q", u.Scheme)
+	}
+
+	query := u.Query()
+	params := OtpParameters{
+		Type:      u.Host,
+		Secret:    query.Get("secret"),
+		Issuer:    query.Get("issuer"),
+		Algorithm: defaultOtpAlgorithm,
+		Digits:    defaultOtpDigits,
+		Period:    totpPeriod,
+	}
+
+	if params.Secret == "" {
+		return OtpParameters{}, fmt.Errorf("invalid auth url: missing secret")
+	}
+
+	// The label is either "account" or "issuer:account", and has already
+	// been percent-decoded by url.Parse.
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		params.Account = strings.TrimSpace(account)
+		if params.Issuer == "" {
+			params.Issuer = issuer
+		}
+	} else {
+		params.Account = label
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		params.Algorithm = strings.ToUpper(algorithm)
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		params.Digits, err = strconv.Atoi(digits)
+		if err != nil {
+			return OtpParameters{}, fmt.Errorf("invalid auth url: invalid digits %q", digits)
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil {
+			return OtpParameters{}, fmt.Errorf("invalid auth url: invalid period %q", period)
+		}
+
+		params.Period = time.Duration(seconds) * time.Second
+	}
+
+	return params, nil
+}
+
+// GetUserOtpParameters fetches the user's provisioning url and returns the
+// parameters encoded in it.
+func (oc *OtpClient) GetUserOtpParameters(ctx context.Context, userId int, opts ...RequestOption) (OtpParameters, error) {
+	otp, err := oc.GetUserOtp(ctx, userId, opts...)
+	if err != nil {
+		return OtpParameters{}, err
+	}
+
+	return ParseAuthUrl(otp.AuthUrl)
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// defaultBatchConcurrency bounds how many requests batch helpers which fan
+// out to per-user endpoints have in flight at once.
+const defaultBatchConcurrency = 8
+
+// WithBatchConcurrency sets how many requests batch methods which fan out to
+// per-user endpoints, such as GetRecoveryCodeCounts, have in flight at once.
+// It defaults to 8.
+func WithBatchConcurrency(n int) Option {
+	return func(oc *OtpClient) {
+		if n < 1 {
+			oc.setConfigErr("batch concurrency must be positive")
+			return
+		}
+
+		oc.batchConcurrency = n
+	}
+}
+
+// batchWorkers returns the client's batch concurrency, which is unset for
+// clients which were not created by New.
+func (oc *OtpClient) batchWorkers() int {
+	if oc.batchConcurrency < 1 {
+		return defaultBatchConcurrency
+	}
+
+	return oc.batchConcurrency
+}
+
+// forEachUser calls fn for every distinct user id, running at most
+// concurrency calls at once, and waits for all of them to finish.
+func forEachUser(userIds []int, concurrency int, fn func(userId int)) {
+	semaphore := make(chan struct{}, concurrency)
+	seen := make(map[int]bool, len(userIds))
+
+	var wg sync.WaitGroup
+	for _, userId := range userIds {
+		if seen[userId] {
+			continue
+		}
+		seen[userId] = true
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(userId int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			fn(userId)
+		}(userId)
+	}
+
+	wg.Wait()
+}
+
+// fanOutRequestOptions returns the options for the per-user requests a batch
+// method makes for n users. Concurrent requests would all fill the caller's
+// ResponseMetadata at once, and it can only describe a single response anyway,
+// so it is only kept if there is at most one request.
+func fanOutRequestOptions(opts []RequestOption, n int) []RequestOption {
+	if n <= 1 {
+		return opts
+	}
+
+	return append(opts[:len(opts):len(opts)], withoutResponseMetadata())
+}
+
+type GetUsersOtpRequest struct {
+	UserIds []int `json:"user_ids"`
+}
+
+type GetUsersOtpResponse struct {
+	Otps map[int]GetUserOtpResponse `json:"otps"`
+}
+
+// GetUsersOtp returns the OTP of several users in a single round trip.
+// Servers without the bulk endpoint are queried for each user concurrently
+// instead. Users whose OTP could not be fetched are reported in the error map,
+// with a NotFoundError for users without OTP.
+func (oc *OtpClient) GetUsersOtp(ctx context.Context, userIds []int, opts ...RequestOption) (map[int]GetUserOtpResponse, map[int]error) {
+	otps := make(map[int]GetUserOtpResponse)
+	errs := make(map[int]error)
+	if len(userIds) == 0 {
+		return otps, errs
+	}
+
+	req := http_client.HttpRequestWithBody[GetUsersOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/users/otp/batch"),
+		},
+		Body: GetUsersOtpRequest{
+			UserIds: userIds,
+		},
+	}
+
+	resp, err := postRequestWithBody[GetUsersOtpRequest, GetUsersOtpResponse](ctx, oc, "GetUsersOtp", req, opts)
+	if err != nil {
+		err = asUnsupportedRoute(err, "bulk otp")
+		if errors.Is(err, errors.ErrUnsupported) {
+			return oc.getUsersOtpIndividually(ctx, userIds, opts)
+		}
+
+		for _, userId := range userIds {
+			errs[userId] = err
+		}
+
+		return otps, errs
+	}
+
+	for _, userId := range userIds {
+		otp, ok := resp.Otps[userId]
+		if !ok {
+			errs[userId] = &NotFoundError{}
+			continue
+		}
+
+		otps[userId] = otp
+	}
+
+	return otps, errs
+}
+
+func (oc *OtpClient) getUsersOtpIndividually(ctx context.Context, userIds []int, opts []RequestOption) (map[int]GetUserOtpResponse, map[int]error) {
+	otps := make(map[int]GetUserOtpResponse)
+	errs := make(map[int]error)
+	opts = fanOutRequestOptions(opts, len(userIds))
+
+	var mu sync.Mutex
+	forEachUser(userIds, oc.batchWorkers(), func(userId int) {
+		otp, err := oc.GetUserOtp(ctx, userId, opts...)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			errs[userId] = err
+			return
+		}
+
+		otps[userId] = otp
+	})
+
+	return otps, errs
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetUsersOtpFallsBackWithoutBulkEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		bulkStatus int
+	}{
+		{"not found", http.StatusNotFound},
+		{"method not allowed", http.StatusMethodNotAllowed},
+		{"not implemented", http.StatusNotImplemented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/users/otp/batch", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.bulkStatus)
+			})
+			mux.HandleFunc("/users/1/otp", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"verified": true, "enabled": true}`))
+			})
+			mux.HandleFunc("/users/2/otp", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			})
+
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			otps, errs := New(srv.URL, "secret").GetUsersOtp(context.Background(), []int{1, 2})
+			if !otps[1].Verified || len(otps) != 1 {
+				t.Errorf("otps = %v, want only user 1", otps)
+			}
+
+			if _, ok := errs[2].(*NotFoundError); !ok || len(errs) != 1 {
+				t.Errorf("errs = %v, want a NotFoundError for user 2", errs)
+			}
+		})
+	}
+}
+
+func TestGetRecoveryCodeCountsWithResponseMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"remaining": 3}`))
+	}))
+	defer srv.Close()
+
+	userIds := make([]int, 32)
+	for i := range userIds {
+		userIds[i] = i + 1
+	}
+
+	// Run with -race: the per-user requests must not all fill md at once.
+	var md ResponseMetadata
+	counts, errs := New(srv.URL, "secret").GetRecoveryCodeCounts(context.Background(), userIds, WithResponseMetadata(&md), WithResponseBodyCopy())
+	if len(counts) != len(userIds) || len(errs) != 0 {
+		t.Fatalf("GetRecoveryCodeCounts() = %v, %v, want a count for every user", counts, errs)
+	}
+
+	if md.StatusCode != 0 {
+		t.Errorf("metadata = %+v, want it untouched", md)
+	}
+}
+
+func TestGetUsersOtpFallbackWithResponseMetadata(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/otp/batch", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": true, "enabled": true}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	userIds := make([]int, 32)
+	for i := range userIds {
+		userIds[i] = i + 1
+	}
+
+	// Run with -race: the per-user requests must not all fill md at once.
+	var md ResponseMetadata
+	otps, errs := New(srv.URL, "secret").GetUsersOtp(context.Background(), userIds, WithResponseMetadata(&md), WithResponseBodyCopy())
+	if len(otps) != len(userIds) || len(errs) != 0 {
+		t.Fatalf("GetUsersOtp() = %v, %v, want an otp for every user", otps, errs)
+	}
+}
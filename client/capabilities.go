@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+type Capabilities struct {
+	Version  string   `json:"version"`
+	Features []string `json:"features"`
+}
+
+// Supports reports whether the server advertises the given feature.
+func (c Capabilities) Supports(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// HealthCheck reports whether the OTP service is up, e.g. for a readiness
+// probe. A ServiceUnhealthyError is returned if the service responded with an
+// error status, and the transport error if it could not be reached.
+func (oc *OtpClient) HealthCheck(ctx context.Context, opts ...RequestOption) error {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint("/health"),
+	}
+
+	err := getRequestWithNoContent(ctx, oc, "HealthCheck", req, opts)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return &ServiceUnhealthyError{apiErr.StatusCode(), apiErr.Problem()}
+	}
+
+	return err
+}
+
+// GetServiceCapabilities returns the server's version and supported features.
+// The result is fetched once and cached for the lifetime of the client; use
+// RefreshServiceCapabilities to fetch them again.
+func (oc *OtpClient) GetServiceCapabilities(ctx context.Context, opts ...RequestOption) (Capabilities, error) {
+	oc.mutex().Lock()
+	capabilities := oc.capabilities
+	oc.mutex().Unlock()
+
+	if capabilities != nil {
+		return *capabilities, nil
+	}
+
+	return oc.RefreshServiceCapabilities(ctx, opts...)
+}
+
+// RefreshServiceCapabilities fetches the server's capabilities, replacing the
+// ones cached by GetServiceCapabilities.
+func (oc *OtpClient) RefreshServiceCapabilities(ctx context.Context, opts ...RequestOption) (Capabilities, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint("/capabilities"),
+	}
+
+	resp, err := getRequest[Capabilities](ctx, oc, "GetServiceCapabilities", req, opts)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	oc.mutex().Lock()
+	oc.capabilities = &resp
+	oc.mutex().Unlock()
+
+	return resp, nil
+}
+
+// APIVersion is the version of the OTP service API this client is written
+// against.
+const APIVersion = "1"
+
+// ServerInfo describes the deployed OTP service.
+type ServerInfo struct {
+	Version    string   `json:"version"`
+	APIVersion string   `json:"api_version"`
+	Features   []string `json:"features"`
+}
+
+// Compatible reports whether the server speaks the API version this client is
+// written against. Servers which do not report an API version are assumed to.
+func (i ServerInfo) Compatible() bool {
+	return i.APIVersion == "" || i.APIVersion == APIVersion
+}
+
+// ServerInfo returns the version of the OTP service and the features it
+// supports, e.g. to warn at startup when the server is not Compatible. Unlike
+// GetServiceCapabilities, it is fetched again on every call. An
+// UnsupportedEndpointError is returned by servers without a version endpoint.
+func (oc *OtpClient) ServerInfo(ctx context.Context, opts ...RequestOption) (ServerInfo, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint("/version"),
+	}
+
+	resp, err := getRequest[ServerInfo](ctx, oc, "ServerInfo", req, opts)
+	if err != nil {
+		return ServerInfo{}, asUnsupportedRoute(err, "server info")
+	}
+
+	return resp, nil
+}
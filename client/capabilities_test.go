@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestServerInfo(t *testing.T) {
+	srv := serve(t, http.StatusOK, `{"version": "1.4.0", "api_version": "1", "features": ["hotp"]}`)
+
+	info, err := New(srv.URL, "secret").ServerInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerInfo() error = %v", err)
+	}
+
+	if info.Version != "1.4.0" || !info.Compatible() {
+		t.Errorf("ServerInfo() = %+v, want version 1.4.0 and compatible", info)
+	}
+}
+
+func TestServerInfoUnsupported(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			srv := serve(t, status, ``)
+
+			_, err := New(srv.URL, "secret").ServerInfo(context.Background())
+			if !errors.Is(err, errors.ErrUnsupported) {
+				t.Errorf("ServerInfo() error = %v, want an UnsupportedEndpointError", err)
+			}
+		})
+	}
+}
+
+func TestCanRegenerateRecoveryCodes(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantAllowed     bool
+		wantRemaining   int
+		wantUnsupported bool
+		wantNotFound    bool
+	}{
+		{"allowed", http.StatusOK, `{"allowed": true, "remaining": 2}`, true, 2, false, false},
+		{"no route", http.StatusNotFound, ``, false, 0, true, false},
+		{"not implemented", http.StatusNotImplemented, ``, false, 0, true, false},
+		{"no otp", http.StatusNotFound, `{"problem": "otp not configured"}`, false, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, tt.status, tt.body)
+
+			allowed, remaining, err := New(srv.URL, "secret").CanRegenerateRecoveryCodes(context.Background(), 1)
+			if errors.Is(err, errors.ErrUnsupported) != tt.wantUnsupported {
+				t.Errorf("CanRegenerateRecoveryCodes() error = %v, want unsupported %v", err, tt.wantUnsupported)
+			}
+
+			if errors.Is(err, ErrNotFound) != tt.wantNotFound {
+				t.Errorf("CanRegenerateRecoveryCodes() error = %v, want not found %v", err, tt.wantNotFound)
+			}
+
+			if allowed != tt.wantAllowed || remaining != tt.wantRemaining {
+				t.Errorf("CanRegenerateRecoveryCodes() = %v, %d, want %v, %d", allowed, remaining, tt.wantAllowed, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestNeedsOtpReEnrollment(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantRequired    bool
+		wantReason      string
+		wantUnsupported bool
+		wantNotFound    bool
+	}{
+		{"required", http.StatusOK, `{"required": true, "reason": "digits changed"}`, true, "digits changed", false, false},
+		{"not required", http.StatusOK, `{"required": false}`, false, "", false, false},
+		{"no route", http.StatusNotFound, ``, false, "", true, false},
+		{"not implemented", http.StatusNotImplemented, ``, false, "", true, false},
+		{"no otp", http.StatusNotFound, `{"problem": "otp not configured"}`, false, "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, tt.status, tt.body)
+
+			required, reason, err := New(srv.URL, "secret").NeedsOtpReEnrollment(context.Background(), 1)
+			if errors.Is(err, errors.ErrUnsupported) != tt.wantUnsupported {
+				t.Errorf("NeedsOtpReEnrollment() error = %v, want unsupported %v", err, tt.wantUnsupported)
+			}
+
+			if errors.Is(err, ErrNotFound) != tt.wantNotFound {
+				t.Errorf("NeedsOtpReEnrollment() error = %v, want not found %v", err, tt.wantNotFound)
+			}
+
+			if required != tt.wantRequired || reason != tt.wantReason {
+				t.Errorf("NeedsOtpReEnrollment() = %v, %q, want %v, %q", required, reason, tt.wantRequired, tt.wantReason)
+			}
+		})
+	}
+}
@@ -1,8 +1,17 @@
 package client
 
 import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 
 	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
 )
@@ -10,25 +19,308 @@ import (
 type OtpClient struct {
 	BaseUrl string
 	Secret  string
+	Logger  *slog.Logger
+	// HTTPClient sends the client's requests if set, e.g. to tune its timeout
+	// or transport. Options configuring the transport, such as
+	// WithDisableKeepAlives, only apply to the client's own default.
+	HTTPClient *http.Client
+	// Timeout bounds every call made by the client, including its retries.
+	// Calls which time out fail with an error wrapping
+	// context.DeadlineExceeded. Zero means no timeout.
+	Timeout time.Duration
+
+	baseURL     *url.URL
+	transport   *http.Transport
+	httpClient  *http.Client
+	retryPolicy retryPolicy
+	retryNotify func(attempt int, err error, nextDelay time.Duration)
+
+	maxRedirects int
+	connections  chan struct{}
+
+	idempotencyCache *idempotencyCache
+	batchConcurrency int
+
+	statusMaxStaleness time.Duration
+	requestStartHeader string
+	requestIDKey       any
+	generateRequestIDs bool
+	maxContentLength   int64
+	maxResponseBytes   int64
+	secretProvider     SecretProvider
+	authenticator      Authenticator
+	clientMetadata     string
+	timeoutError       func(operation string, d time.Duration) error
+	logSampleRate      float64
+	requestLogger      RequestLogger
+	tracer             Tracer
+	doer               Doer
+	metricsRecorder    MetricsRecorder
+	deprecationNotify  func(DeprecationNotice)
+
+	offlineValidationWindow *int
+	offlineTotpDigits       int
+	requireHTTPS            bool
+	allowInsecure           bool
+
+	expectContinueThreshold int
+	parseNotFoundBody       bool
+	strictJSON              bool
+	userAgent               string
+	configErr               error
+
+	mu            *sync.Mutex
+	lastRateLimit *RateLimitInfo
+	lastTLS       *tls.ConnectionState
+	statusCache   map[int]cachedOtpStatus
+	capabilities  *Capabilities
+}
+
+// New creates a client for the OTP service at baseUrl, which may end in a
+// slash. If baseUrl or any of the options are invalid, every request made by
+// the client fails with the resulting ConfigurationError; use
+// NewOtpClientWithOptions to detect this at construction instead.
+func New(baseUrl, secret string, opts ...Option) *OtpClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	oc := &OtpClient{
+		BaseUrl:          baseUrl,
+		Secret:           secret,
+		mu:               &sync.Mutex{},
+		transport:        transport,
+		logSampleRate:    1,
+		maxRedirects:     defaultMaxRedirects,
+		batchConcurrency: defaultBatchConcurrency,
+		maxResponseBytes: defaultMaxResponseBytes,
+		authenticator:    HeaderAuthenticator(DefaultAuthHeader),
+	}
+	oc.httpClient = &http.Client{
+		Transport:     transport,
+		CheckRedirect: oc.checkRedirect,
+	}
+
+	for _, opt := range opts {
+		opt(oc)
+	}
+
+	oc.checkScheme()
+	return oc
+}
+
+// NewOtpClient is like New, but returns the client by value.
+func NewOtpClient(baseUrl, secret string, opts ...Option) OtpClient {
+	return *New(baseUrl, secret, opts...)
+}
+
+// fallbackMu guards the state of clients which were not created by New.
+var fallbackMu sync.Mutex
+
+// mutex returns the lock guarding the client's mutable state. It is shared by
+// copies of the client, as NewOtpClient returns it by value.
+func (oc *OtpClient) mutex() *sync.Mutex {
+	if oc.mu == nil {
+		return &fallbackMu
+	}
+
+	return oc.mu
+}
+
+// NewOtpClientFromURL is like NewOtpClientWithOptions, but takes an already
+// parsed base url, which is used as is to build request urls. The url must be
+// absolute and use http or https.
+func NewOtpClientFromURL(u *url.URL, secret string, opts ...Option) (*OtpClient, error) {
+	if err := validateBaseURL(u); err != nil {
+		return nil, err
+	}
+
+	oc, err := NewOtpClientWithOptions(u.String(), secret, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	oc.baseURL = u
+	return oc, nil
+}
+
+func validateBaseURL(u *url.URL) error {
+	if u == nil {
+		return &ConfigurationError{"base url is missing"}
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &ConfigurationError{fmt.Sprintf("base url %q must use http or https", u.Redacted())}
+	}
+
+	if u.Host == "" {
+		return &ConfigurationError{fmt.Sprintf("base url %q has no host", u.Redacted())}
+	}
+
+	return nil
+}
+
+// endpoint returns the url of the given path on the OTP service, below any
+// path of the base url and regardless of whether it ends in a slash.
+func (oc *OtpClient) endpoint(path string) string {
+	baseUrl := oc.baseURL
+	if baseUrl == nil {
+		var err error
+		baseUrl, err = url.Parse(oc.BaseUrl)
+		if err != nil {
+			// Requests are refused by prepareRequest before reaching this url.
+			return oc.BaseUrl + path
+		}
+	}
+
+	return baseUrl.JoinPath(path).String()
+}
+
+// NewOtpClientWithOptions is like New, but returns an error if the
+// resulting client fails Validate, e.g. because baseUrl is not an absolute
+// http or https url.
+func NewOtpClientWithOptions(baseUrl, secret string, opts ...Option) (*OtpClient, error) {
+	oc := New(baseUrl, secret, opts...)
+	if err := oc.Validate(); err != nil {
+		return nil, err
+	}
+
+	return oc, nil
+}
+
+// Validate checks the client's configuration, returning a ConfigurationError
+// describing the first problem found. It can be used to fail fast at startup
+// when a client's fields are set after it was created.
+func (oc *OtpClient) Validate() error {
+	if oc.configErr != nil {
+		return oc.configErr
+	}
+
+	baseUrl, err := oc.parseBaseURL()
+	if err != nil {
+		return err
+	}
+
+	if oc.requireHTTPS && !oc.allowInsecure && baseUrl.Scheme != "https" {
+		return &ConfigurationError{fmt.Sprintf("base url %q must use https", baseUrl.Redacted())}
+	}
+
+	oc.mutex().Lock()
+	secret := oc.Secret
+	oc.mutex().Unlock()
+
+	if secret == "" && oc.secretProvider == nil {
+		return &ConfigurationError{"secret is missing"}
+	}
+
+	if oc.Timeout < 0 {
+		return &ConfigurationError{"timeout must not be negative"}
+	}
+
+	if oc.retryPolicy.maxAttempts < 0 || oc.retryPolicy.baseDelay < 0 {
+		return &ConfigurationError{"retry attempts and delay must not be negative"}
+	}
+
+	return nil
+}
+
+// parseBaseURL returns the client's base url, or a ConfigurationError if it
+// is not an absolute http or https url.
+func (oc *OtpClient) parseBaseURL() (*url.URL, error) {
+	if oc.baseURL != nil {
+		return oc.baseURL, validateBaseURL(oc.baseURL)
+	}
+
+	baseUrl, err := url.Parse(oc.BaseUrl)
+	if err != nil {
+		return nil, &ConfigurationError{"base url is malformed"}
+	}
+
+	if err := validateBaseURL(baseUrl); err != nil {
+		return nil, err
+	}
+
+	return baseUrl, nil
+}
+
+// SetSecret replaces the secret used to authenticate with the OTP service.
+// Requests already in flight keep using the previous secret.
+func (oc *OtpClient) SetSecret(secret string) {
+	oc.mutex().Lock()
+	defer oc.mutex().Unlock()
+
+	oc.Secret = secret
+}
+
+func (oc *OtpClient) secret(ctx context.Context) (string, error) {
+	if oc.secretProvider != nil {
+		return oc.secretProvider.Secret(ctx)
+	}
+
+	oc.mutex().Lock()
+	defer oc.mutex().Unlock()
+
+	return oc.Secret, nil
 }
 
-func NewOtpClient(baseUrl, secret string) OtpClient {
-	return OtpClient{baseUrl, secret}
+func (oc *OtpClient) client() Doer {
+	doer := oc.httpDoer()
+	if oc.requestStartHeader != "" {
+		doer = &requestStartDoer{doer, oc.requestStartHeader}
+	}
+
+	if oc.tracer != nil {
+		doer = &tracingDoer{doer, oc.tracer}
+	}
+
+	return doer
+}
+
+func (oc *OtpClient) httpDoer() Doer {
+	if oc.doer != nil {
+		return oc.doer
+	}
+
+	if oc.HTTPClient != nil {
+		if oc.HTTPClient.CheckRedirect != nil {
+			return oc.HTTPClient
+		}
+
+		// Keep the secret from leaking through redirects to other hosts or http.
+		return withCheckRedirect(oc.HTTPClient, oc.checkRedirect)
+	}
+
+	if oc.httpClient == nil {
+		return http.DefaultClient
+	}
+
+	return oc.httpClient
+}
+
+func withCheckRedirect(client *http.Client, checkRedirect func(*http.Request, []*http.Request) error) *http.Client {
+	withCheckRedirect := *client
+	withCheckRedirect.CheckRedirect = checkRedirect
+	return &withCheckRedirect
 }
 
 func handleResponse(resp http_client.HttpResponse) error {
 	if resp.StatusCode == http.StatusNotFound {
-		return &NotFoundError{}
+		return &NotFoundError{resp.ErrorBody.Problem}
 	}
 
 	if resp.HasError {
 		switch resp.StatusCode {
 		case http.StatusBadRequest:
 			return &BadRequestError{resp.ErrorBody.Problem}
+		case http.StatusUnauthorized:
+			return &UnauthorizedError{resp.ErrorBody.Problem}
+		case http.StatusForbidden:
+			return &ForbiddenError{resp.ErrorBody.Problem}
 		case http.StatusConflict:
 			return &ConflictError{resp.ErrorBody.Problem}
+		case http.StatusTooManyRequests:
+			retryAfter, _ := parseRetryAfter(resp.Headers, time.Now())
+			return &TooManyRequestsError{resp.ErrorBody.Problem, retryAfter}
 		default:
-			return &UnknownError{resp.ErrorBody.Problem}
+			return &UnknownError{resp.ErrorBody.Problem, resp.StatusCode}
 		}
 	}
 
@@ -45,15 +337,88 @@ func handleResponseWithBody[T any](resp http_client.HttpResponseWithBody[T]) (T,
 	return resp.Body, nil
 }
 
-func prepareRequest(oc *OtpClient, request http_client.HttpRequestWithHeaders) {
-	request.AddHeader("X-Secret", oc.Secret)
+func prepareRequest(ctx context.Context, oc *OtpClient, request *http_client.HttpRequest, opts []RequestOption) error {
+	if oc.configErr != nil {
+		return oc.configErr
+	}
+
+	// Fail with a description of the problem rather than the transport's
+	// "unsupported protocol scheme".
+	if _, err := oc.parseBaseURL(); err != nil {
+		return err
+	}
+
+	options := applyRequestOptions(opts)
+
+	secret, err := oc.secret(ctx)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range options.headers {
+		request.AddHeader(key, value)
+	}
+
+	if oc.clientMetadata != "" {
+		request.SetHeader(ClientMetadataHeader, oc.clientMetadata)
+	}
+
+	if requestID := oc.requestID(ctx); requestID != "" {
+		request.SetHeader(RequestIDHeader, requestID)
+	}
+
+	authHeaders := &recordingHeaders{request: request}
+	oc.auth().Apply(authHeaders, secret)
+	request.SensitiveHeaders = authHeaders.names
+	request.MaxContentLength = oc.maxContentLength
+	request.MaxBodyBytes = oc.maxResponseBytes
+	request.ExpectContinueThreshold = oc.expectContinueThreshold
+	request.ParseNotFoundBody = request.ParseNotFoundBody || oc.parseNotFoundBody
+	request.StrictJSON = oc.strictJSON
+	request.RetainBody = options.copyBody
+	request.UserAgent = oc.userAgent
+	return nil
 }
 
-func getRequest[T any](oc *OtpClient, request http_client.HttpRequest) (T, error) {
-	prepareRequest(oc, &request)
+func observeResponse(oc *OtpClient, resp http_client.HttpResponse) {
+	if resp.StatusCode == 0 {
+		return
+	}
+
+	oc.mutex().Lock()
+	defer oc.mutex().Unlock()
+
+	if resp.TLS != nil {
+		oc.lastTLS = resp.TLS
+	}
 
+	if rateLimit, ok := parseRateLimit(resp.Headers, time.Now()); ok {
+		oc.lastRateLimit = &rateLimit
+	}
+}
+
+// LastTLSConnectionState returns the TLS state of the connection behind the
+// most recent response received over https, or nil if there was none. It can
+// be used to check which TLS version and cipher suite were negotiated.
+func (oc *OtpClient) LastTLSConnectionState() *tls.ConnectionState {
+	oc.mutex().Lock()
+	defer oc.mutex().Unlock()
+
+	return oc.lastTLS
+}
+
+func getRequest[T any](ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequest, opts []RequestOption) (T, error) {
 	var def T
-	resp, err := http_client.Get[T](request)
+	if err := prepareRequest(ctx, oc, &request, opts); err != nil {
+		return def, err
+	}
+
+	var resp http_client.HttpResponseWithBody[T]
+	err := execute(ctx, oc, op, http.MethodGet, request, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.Get[T](ctx, oc.client(), request)
+		return resp.HttpResponse, err
+	})
 	if err != nil {
 		return def, err
 	}
@@ -61,11 +426,36 @@ func getRequest[T any](oc *OtpClient, request http_client.HttpRequest) (T, error
 	return handleResponseWithBody[T](resp)
 }
 
-func postRequest[T any](oc *OtpClient, request http_client.HttpRequest) (T, error) {
-	prepareRequest(oc, &request)
+func getRequestWithNoContent(ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequest, opts []RequestOption) error {
+	if err := prepareRequest(ctx, oc, &request, opts); err != nil {
+		return err
+	}
+
+	var resp http_client.HttpResponse
+	err := execute(ctx, oc, op, http.MethodGet, request, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.GetWithNoContent(ctx, oc.client(), request)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+
+	return handleResponse(resp)
+}
 
+func postRequest[T any](ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequest, opts []RequestOption) (T, error) {
 	var def T
-	resp, err := http_client.Post[T](request)
+	if err := prepareRequest(ctx, oc, &request, opts); err != nil {
+		return def, err
+	}
+
+	var resp http_client.HttpResponseWithBody[T]
+	err := execute(ctx, oc, op, http.MethodPost, request, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.Post[T](ctx, oc.client(), request)
+		return resp.HttpResponse, err
+	})
 	if err != nil {
 		return def, err
 	}
@@ -73,10 +463,17 @@ func postRequest[T any](oc *OtpClient, request http_client.HttpRequest) (T, erro
 	return handleResponseWithBody[T](resp)
 }
 
-func postRequestWithNoContent(oc *OtpClient, request http_client.HttpRequest) error {
-	prepareRequest(oc, &request)
+func postRequestWithNoContent(ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequest, opts []RequestOption) error {
+	if err := prepareRequest(ctx, oc, &request, opts); err != nil {
+		return err
+	}
 
-	resp, err := http_client.PostWithNoContent(request)
+	var resp http_client.HttpResponse
+	err := execute(ctx, oc, op, http.MethodPost, request, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.PostWithNoContent(ctx, oc.client(), request)
+		return resp, err
+	})
 	if err != nil {
 		return err
 	}
@@ -84,10 +481,17 @@ func postRequestWithNoContent(oc *OtpClient, request http_client.HttpRequest) er
 	return handleResponse(resp)
 }
 
-func postRequestWithBodyWithNoContent[T any](oc *OtpClient, request http_client.HttpRequestWithBody[T]) error {
-	prepareRequest(oc, &request)
+func postRequestWithBodyWithNoContent[T any](ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequestWithBody[T], opts []RequestOption) error {
+	if err := prepareRequest(ctx, oc, &request.HttpRequest, opts); err != nil {
+		return err
+	}
 
-	resp, err := http_client.PostWithBodyWithNoContent(request)
+	var resp http_client.HttpResponse
+	err := execute(ctx, oc, op, http.MethodPost, request.HttpRequest, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.PostWithBodyWithNoContent(ctx, oc.client(), request)
+		return resp, err
+	})
 	if err != nil {
 		return err
 	}
@@ -95,11 +499,27 @@ func postRequestWithBodyWithNoContent[T any](oc *OtpClient, request http_client.
 	return handleResponse(resp)
 }
 
-func postRequestWithBody[T any, T1 any](oc *OtpClient, request http_client.HttpRequestWithBody[T]) (T1, error) {
-	prepareRequest(oc, &request)
+// postRequestWithBodyWithNoContentResponse is like
+// postRequestWithBodyWithNoContent, but returns the response without mapping
+// error statuses, so that any response body can be inspected.
+func postRequestWithBodyWithNoContentResponse[T any](ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequestWithBody[T], opts []RequestOption) (http_client.HttpResponse, error) {
+	if err := prepareRequest(ctx, oc, &request.HttpRequest, opts); err != nil {
+		return http_client.HttpResponse{}, err
+	}
+
+	var resp http_client.HttpResponse
+	err := execute(ctx, oc, op, http.MethodPost, request.HttpRequest, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.PostWithBodyWithNoContent(ctx, oc.client(), request)
+		return resp, err
+	})
 
+	return resp, err
+}
+
+func postRequestWithBody[T any, T1 any](ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequestWithBody[T], opts []RequestOption) (T1, error) {
 	var def T1
-	resp, err := http_client.PostWithBody[T, T1](request)
+	resp, err := postRequestWithBodyResponse[T, T1](ctx, oc, op, request, opts)
 	if err != nil {
 		return def, err
 	}
@@ -107,10 +527,53 @@ func postRequestWithBody[T any, T1 any](oc *OtpClient, request http_client.HttpR
 	return handleResponseWithBody[T1](resp)
 }
 
-func deleteRequestWithNoContent(oc *OtpClient, request http_client.HttpRequest) error {
-	prepareRequest(oc, &request)
+// postRequestWithBodyResponse is like postRequestWithBody, but returns the
+// response without mapping error statuses, so that the bodies of error
+// responses can be inspected.
+func postRequestWithBodyResponse[T any, T1 any](ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequestWithBody[T], opts []RequestOption) (http_client.HttpResponseWithBody[T1], error) {
+	if err := prepareRequest(ctx, oc, &request.HttpRequest, opts); err != nil {
+		return http_client.HttpResponseWithBody[T1]{}, err
+	}
+
+	var resp http_client.HttpResponseWithBody[T1]
+	err := execute(ctx, oc, op, http.MethodPost, request.HttpRequest, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.PostWithBody[T, T1](ctx, oc.client(), request)
+		return resp.HttpResponse, err
+	})
+
+	return resp, err
+}
+
+func deleteRequestWithNoContent(ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequest, opts []RequestOption) error {
+	if err := prepareRequest(ctx, oc, &request, opts); err != nil {
+		return err
+	}
 
-	resp, err := http_client.DeleteWithNoContent(request)
+	var resp http_client.HttpResponse
+	err := execute(ctx, oc, op, http.MethodDelete, request, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.DeleteWithNoContent(ctx, oc.client(), request)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+
+	return handleResponse(resp)
+}
+
+func deleteRequestWithBodyWithNoContent[T any](ctx context.Context, oc *OtpClient, op string, request http_client.HttpRequestWithBody[T], opts []RequestOption) error {
+	if err := prepareRequest(ctx, oc, &request.HttpRequest, opts); err != nil {
+		return err
+	}
+
+	var resp http_client.HttpResponse
+	err := execute(ctx, oc, op, http.MethodDelete, request.HttpRequest, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		var err error
+		resp, err = http_client.DeleteWithBodyWithNoContent(ctx, oc.client(), request)
+		return resp, err
+	})
 	if err != nil {
 		return err
 	}
@@ -125,12 +588,12 @@ type GetUserOtpResponse struct {
 	AuthUrl  string `json:"auth_url"`
 }
 
-func (oc *OtpClient) GetUserOtp(userId int) (GetUserOtpResponse, error) {
+func (oc *OtpClient) GetUserOtp(ctx context.Context, userId int, opts ...RequestOption) (GetUserOtpResponse, error) {
 	req := http_client.HttpRequest{
-		Url: oc.BaseUrl + fmt.Sprintf("/users/%d/otp", userId),
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp", userId)),
 	}
 
-	resp, err := getRequest[GetUserOtpResponse](oc, req)
+	resp, err := getRequest[GetUserOtpResponse](ctx, oc, "GetUserOtp", req, opts)
 	if err != nil {
 		return GetUserOtpResponse{}, err
 	}
@@ -138,17 +601,41 @@ func (oc *OtpClient) GetUserOtp(userId int) (GetUserOtpResponse, error) {
 	return resp, nil
 }
 
+// IsUserOtpVerified reports whether the user's OTP is verified, which is false
+// for users without an OTP.
+func (oc *OtpClient) IsUserOtpVerified(ctx context.Context, userId int, opts ...RequestOption) (bool, error) {
+	resp, err := oc.GetUserOtp(ctx, userId, opts...)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return resp.Verified, nil
+}
+
 type CreateUserOtpResponse struct {
 	Secret  string `json:"secret"`
 	AuthUrl string `json:"auth_url"`
+	// OtpId identifies the OTP device on multi-device servers, it is empty
+	// on servers which only support a single device per user.
+	OtpId string `json:"otp_id,omitempty"`
 }
 
-func (oc *OtpClient) CreateUserOtp(userId int) (CreateUserOtpResponse, error) {
+// CreateUserOtp creates a new OTP secret for the user. The request carries an
+// Idempotency-Key header, which makes it safe to retry under the client's
+// retry policy without orphaning secrets.
+func (oc *OtpClient) CreateUserOtp(ctx context.Context, userId int, opts ...RequestOption) (CreateUserOtpResponse, error) {
 	req := http_client.HttpRequest{
-		Url: oc.BaseUrl + fmt.Sprintf("/users/%d/otp", userId),
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp", userId)),
 	}
+	setIdempotencyKey(&req, opts)
 
-	resp, err := postRequest[CreateUserOtpResponse](oc, req)
+	resp, err := withIdempotencyCache(ctx, oc, "CreateUserOtp", opts, func() (CreateUserOtpResponse, error) {
+		return postRequest[CreateUserOtpResponse](ctx, oc, "CreateUserOtp", req, opts)
+	})
 	if err != nil {
 		return CreateUserOtpResponse{}, err
 	}
@@ -156,12 +643,12 @@ func (oc *OtpClient) CreateUserOtp(userId int) (CreateUserOtpResponse, error) {
 	return resp, nil
 }
 
-func (oc *OtpClient) DisableUserOtp(userId int) error {
+func (oc *OtpClient) DisableUserOtp(ctx context.Context, userId int, opts ...RequestOption) error {
 	req := http_client.HttpRequest{
-		Url: oc.BaseUrl + fmt.Sprintf("/users/%d/otp/disable", userId),
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/disable", userId)),
 	}
 
-	err := postRequestWithNoContent(oc, req)
+	err := postRequestWithNoContent(ctx, oc, "DisableUserOtp", req, opts)
 	if err != nil {
 		return err
 	}
@@ -169,12 +656,49 @@ func (oc *OtpClient) DisableUserOtp(userId int) error {
 	return nil
 }
 
-func (oc *OtpClient) DeleteUserOtp(userId int) error {
+// EnableUserOtp enforces the user's existing OTP again, e.g. once they have
+// confirmed a token or after DisableUserOtp, without rotating its secret.
+func (oc *OtpClient) EnableUserOtp(ctx context.Context, userId int, opts ...RequestOption) error {
 	req := http_client.HttpRequest{
-		Url: oc.BaseUrl + fmt.Sprintf("/users/%d/otp", userId),
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/enable", userId)),
+	}
+
+	err := postRequestWithNoContent(ctx, oc, "EnableUserOtp", req, opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (oc *OtpClient) DeleteUserOtp(ctx context.Context, userId int, opts ...RequestOption) error {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp", userId)),
+	}
+
+	err := deleteRequestWithNoContent(ctx, oc, "DeleteUserOtp", req, opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type DeleteUserOtpRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (oc *OtpClient) DeleteUserOtpWithReason(ctx context.Context, userId int, reason string, opts ...RequestOption) error {
+	req := http_client.HttpRequestWithBody[DeleteUserOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint(fmt.Sprintf("/users/%d/otp", userId)),
+		},
+		Body: DeleteUserOtpRequest{
+			Reason: reason,
+		},
 	}
 
-	err := deleteRequestWithNoContent(oc, req)
+	err := deleteRequestWithBodyWithNoContent[DeleteUserOtpRequest](ctx, oc, "DeleteUserOtpWithReason", req, opts)
 	if err != nil {
 		return err
 	}
@@ -182,15 +706,59 @@ func (oc *OtpClient) DeleteUserOtp(userId int) error {
 	return nil
 }
 
+type GetUserOtpPolicyResponse struct {
+	Required bool `json:"required"`
+}
+
+// IsOtpRequired reports whether the user's policy mandates OTP, regardless of
+// whether they currently have it enabled.
+func (oc *OtpClient) IsOtpRequired(ctx context.Context, userId int, opts ...RequestOption) (bool, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/policy", userId)),
+	}
+
+	resp, err := getRequest[GetUserOtpPolicyResponse](ctx, oc, "IsOtpRequired", req, opts)
+	if err != nil {
+		return false, err
+	}
+
+	return resp.Required, nil
+}
+
+type GetUserOtpReEnrollmentResponse struct {
+	Required bool   `json:"required"`
+	Reason   string `json:"reason"`
+}
+
+// NeedsOtpReEnrollment reports whether the user's enrollment predates a change
+// of the server's TOTP parameters and should be refreshed, along with the
+// server's reason. A NotFoundError is returned if the user has no OTP, and an
+// UnsupportedEndpointError by servers without re-enrollment checks.
+func (oc *OtpClient) NeedsOtpReEnrollment(ctx context.Context, userId int, opts ...RequestOption) (bool, string, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/re-enrollment", userId)),
+		// The server explains why a user was not found, unlike a 404 for the
+		// route itself.
+		ParseNotFoundBody: true,
+	}
+
+	resp, err := getRequest[GetUserOtpReEnrollmentResponse](ctx, oc, "NeedsOtpReEnrollment", req, opts)
+	if err != nil {
+		return false, "", asUnsupportedRoute(err, "re-enrollment checks")
+	}
+
+	return resp.Required, resp.Reason, nil
+}
+
 type VerifyOtpRequest struct {
 	UserId int    `json:"user_id"`
 	Token  string `json:"token"`
 }
 
-func (oc *OtpClient) VerifyOtp(userId int, token string) error {
+func (oc *OtpClient) VerifyOtp(ctx context.Context, userId int, token string, opts ...RequestOption) error {
 	req := http_client.HttpRequestWithBody[VerifyOtpRequest]{
 		HttpRequest: http_client.HttpRequest{
-			Url: oc.BaseUrl + "/otp/verify",
+			Url: oc.endpoint("/otp/verify"),
 		},
 		Body: VerifyOtpRequest{
 			UserId: userId,
@@ -198,7 +766,7 @@ func (oc *OtpClient) VerifyOtp(userId int, token string) error {
 		},
 	}
 
-	err := postRequestWithBodyWithNoContent[VerifyOtpRequest](oc, req)
+	err := postRequestWithBodyWithNoContent[VerifyOtpRequest](ctx, oc, "VerifyOtp", req, opts)
 	if err != nil {
 		return err
 	}
@@ -206,15 +774,207 @@ func (oc *OtpClient) VerifyOtp(userId int, token string) error {
 	return nil
 }
 
+type VerifyOtpResponse struct {
+	RemainingAttempts int
+	// LockedUntil is the zero time unless the user is locked out of
+	// verification.
+	LockedUntil time.Time
+}
+
+// VerifyOtpWithResult verifies a token like VerifyOtp, additionally returning
+// how many attempts the user has left and whether they are locked out, as far
+// as the server reported them. The result is also returned alongside the error
+// when the token is rejected.
+func (oc *OtpClient) VerifyOtpWithResult(ctx context.Context, userId int, token string, opts ...RequestOption) (VerifyOtpResponse, error) {
+	req := http_client.HttpRequestWithBody[VerifyOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/otp/verify"),
+		},
+		Body: VerifyOtpRequest{
+			UserId: userId,
+			Token:  token,
+		},
+	}
+
+	resp, err := postRequestWithBodyWithNoContentResponse[VerifyOtpRequest](ctx, oc, "VerifyOtpWithResult", req, opts)
+	if err != nil {
+		return VerifyOtpResponse{}, err
+	}
+
+	// The body is optional, so one which is absent or not an attempt report
+	// leaves the result empty.
+	var body validateOtpOutcomeResponse
+	_ = json.Unmarshal(resp.RawBody, &body)
+
+	outcome := body.outcome(false)
+	result := VerifyOtpResponse{
+		RemainingAttempts: outcome.RemainingAttempts,
+		LockedUntil:       outcome.LockedUntil,
+	}
+
+	return result, handleResponse(resp)
+}
+
+// VerifySetupOtp verifies a token during enrollment, before the user's OTP is
+// enabled. A ConflictError is returned if it is already enabled; use
+// ValidateOtp for ongoing logins instead.
+func (oc *OtpClient) VerifySetupOtp(ctx context.Context, userId int, token string, opts ...RequestOption) error {
+	req := http_client.HttpRequestWithBody[VerifyOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/otp/setup/verify"),
+		},
+		Body: VerifyOtpRequest{
+			UserId: userId,
+			Token:  token,
+		},
+	}
+
+	err := postRequestWithBodyWithNoContent[VerifyOtpRequest](ctx, oc, "VerifySetupOtp", req, opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type SessionToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type verifyOtpSessionResponse struct {
+	SessionToken string `json:"session_token"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+// VerifyOtpForSession verifies a token like VerifyOtp, and returns the session
+// token issued by the server for the successful verification.
+func (oc *OtpClient) VerifyOtpForSession(ctx context.Context, userId int, token string, opts ...RequestOption) (SessionToken, error) {
+	req := http_client.HttpRequestWithBody[VerifyOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/otp/verify"),
+			// Older servers respond without a body.
+			AllowEmptyBody: true,
+		},
+		Body: VerifyOtpRequest{
+			UserId: userId,
+			Token:  token,
+		},
+	}
+
+	resp, err := postRequestWithBody[VerifyOtpRequest, verifyOtpSessionResponse](ctx, oc, "VerifyOtpForSession", req, opts)
+	if err != nil {
+		return SessionToken{}, err
+	}
+
+	if resp.SessionToken == "" {
+		return SessionToken{}, ErrNoSessionToken
+	}
+
+	return SessionToken{
+		Token:     resp.SessionToken,
+		ExpiresAt: time.Unix(resp.ExpiresAt, 0),
+	}, nil
+}
+
+type VerifyOtpWithNonceRequest struct {
+	UserId int    `json:"user_id"`
+	Token  string `json:"token"`
+	Nonce  string `json:"nonce"`
+}
+
+type VerifyOtpWithNonceResponse struct {
+	Nonce string `json:"nonce"`
+}
+
+// VerifyOtpWithNonce verifies a token like VerifyOtp, sending along a nonce
+// which the server echoes back. The echoed nonce is returned, together with a
+// NonceMismatchError if it differs from the one sent.
+func (oc *OtpClient) VerifyOtpWithNonce(ctx context.Context, userId int, token, nonce string, opts ...RequestOption) (string, error) {
+	req := http_client.HttpRequestWithBody[VerifyOtpWithNonceRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/otp/verify"),
+			// A response without a body echoes no nonce, which is a mismatch.
+			AllowEmptyBody: true,
+		},
+		Body: VerifyOtpWithNonceRequest{
+			UserId: userId,
+			Token:  token,
+			Nonce:  nonce,
+		},
+	}
+
+	resp, err := postRequestWithBody[VerifyOtpWithNonceRequest, VerifyOtpWithNonceResponse](ctx, oc, "VerifyOtpWithNonce", req, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(resp.Nonce), []byte(nonce)) != 1 {
+		return resp.Nonce, &NonceMismatchError{nonce, resp.Nonce}
+	}
+
+	return resp.Nonce, nil
+}
+
+type VerifyOtpTrustDeviceRequest struct {
+	UserId      int    `json:"user_id"`
+	Token       string `json:"token"`
+	DeviceLabel string `json:"device_label"`
+}
+
+type TrustedDevice struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type verifyOtpTrustDeviceResponse struct {
+	DeviceToken string `json:"device_token"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+// VerifyOtpTrustDevice verifies a token like VerifyOtp, and returns the
+// trusted device token issued by the server for the device with the given
+// label. The device is only trusted if the verification succeeds, and
+// ErrNoDeviceToken is returned if the server issued no token for it.
+func (oc *OtpClient) VerifyOtpTrustDevice(ctx context.Context, userId int, token string, deviceLabel string, opts ...RequestOption) (TrustedDevice, error) {
+	req := http_client.HttpRequestWithBody[VerifyOtpTrustDeviceRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url:            oc.endpoint("/otp/verify/trust-device"),
+			AllowEmptyBody: true,
+		},
+		Body: VerifyOtpTrustDeviceRequest{
+			UserId:      userId,
+			Token:       token,
+			DeviceLabel: deviceLabel,
+		},
+	}
+
+	resp, err := postRequestWithBody[VerifyOtpTrustDeviceRequest, verifyOtpTrustDeviceResponse](ctx, oc, "VerifyOtpTrustDevice", req, opts)
+	if err != nil {
+		return TrustedDevice{}, err
+	}
+
+	if resp.DeviceToken == "" {
+		return TrustedDevice{}, ErrNoDeviceToken
+	}
+
+	return TrustedDevice{
+		Token:     resp.DeviceToken,
+		ExpiresAt: time.Unix(resp.ExpiresAt, 0),
+	}, nil
+}
+
 type ValidateOtpRequest struct {
 	UserId int    `json:"user_id"`
 	Token  string `json:"token"`
 }
 
-func (oc *OtpClient) ValidateOtp(userId int, token string) error {
+// ValidateOtp validates a token for a login. A successfully validated token
+// is consumed by the server and cannot be used again.
+func (oc *OtpClient) ValidateOtp(ctx context.Context, userId int, token string, opts ...RequestOption) error {
 	req := http_client.HttpRequestWithBody[ValidateOtpRequest]{
 		HttpRequest: http_client.HttpRequest{
-			Url: oc.BaseUrl + "/otp/validate",
+			Url: oc.endpoint("/otp/validate"),
 		},
 		Body: ValidateOtpRequest{
 			UserId: userId,
@@ -222,7 +982,7 @@ func (oc *OtpClient) ValidateOtp(userId int, token string) error {
 		},
 	}
 
-	err := postRequestWithBodyWithNoContent[ValidateOtpRequest](oc, req)
+	err := postRequestWithBodyWithNoContent[ValidateOtpRequest](ctx, oc, "ValidateOtp", req, opts)
 	if err != nil {
 		return err
 	}
@@ -230,17 +990,452 @@ func (oc *OtpClient) ValidateOtp(userId int, token string) error {
 	return nil
 }
 
+type ValidateOutcome struct {
+	Valid             bool
+	RemainingAttempts int
+	// RateLimited is set if the token was rejected because the user made too
+	// many attempts, rather than because it is wrong.
+	RateLimited bool
+	// LockedUntil is the zero time unless the user is locked out of
+	// validation.
+	LockedUntil time.Time
+}
+
+type validateOtpOutcomeResponse struct {
+	RemainingAttempts int    `json:"remaining_attempts"`
+	LockedUntil       *int64 `json:"locked_until"`
+}
+
+func (r validateOtpOutcomeResponse) outcome(valid bool) ValidateOutcome {
+	outcome := ValidateOutcome{
+		Valid:             valid,
+		RemainingAttempts: r.RemainingAttempts,
+	}
+
+	if r.LockedUntil != nil {
+		outcome.LockedUntil = time.Unix(*r.LockedUntil, 0)
+	}
+
+	return outcome
+}
+
+// ValidateOtpWithOutcome validates a token like ValidateOtp, but reports a
+// rejected token as an invalid outcome rather than an error, along with how
+// many attempts the user has left before being locked out.
+func (oc *OtpClient) ValidateOtpWithOutcome(ctx context.Context, userId int, token string, opts ...RequestOption) (ValidateOutcome, error) {
+	return oc.validateOtpWithOutcome(ctx, "ValidateOtpWithOutcome", userId, token, opts)
+}
+
+func (oc *OtpClient) validateOtpWithOutcome(ctx context.Context, op string, userId int, token string, opts []RequestOption) (ValidateOutcome, error) {
+	req := http_client.HttpRequestWithBody[ValidateOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/otp/validate"),
+		},
+		Body: ValidateOtpRequest{
+			UserId: userId,
+			Token:  token,
+		},
+	}
+
+	resp, err := postRequestWithBodyResponse[ValidateOtpRequest, validateOtpOutcomeResponse](ctx, oc, op, req, opts)
+	if err != nil {
+		return ValidateOutcome{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusTooManyRequests:
+		// Error bodies are not decoded, but these may carry the attempt
+		// details. One which is absent or malformed leaves them empty, as
+		// the token was rejected either way.
+		var body validateOtpOutcomeResponse
+		_ = json.Unmarshal(resp.RawBody, &body)
+
+		outcome := body.outcome(false)
+		outcome.RateLimited = resp.StatusCode == http.StatusTooManyRequests
+		return outcome, nil
+	}
+
+	err = handleResponse(resp.HttpResponse)
+	if err != nil {
+		return ValidateOutcome{}, err
+	}
+
+	return resp.Body.outcome(true), nil
+}
+
+// ValidateResult is the verdict on a token validated by ValidateOtpWithResult.
+type ValidateResult int
+
+const (
+	// ValidateResultValid means the token was accepted and consumed.
+	ValidateResultValid ValidateResult = iota + 1
+	// ValidateResultInvalid means the token was wrong or has expired, so the
+	// user can try again with another one.
+	ValidateResultInvalid
+	// ValidateResultRateLimited means the user made too many attempts and
+	// has to wait before trying again.
+	ValidateResultRateLimited
+)
+
+// ValidateOtpWithResult validates a token like ValidateOtpWithOutcome, but
+// only reports the verdict on it as a ValidateResult. An error is only
+// returned if the token could not be validated at all, e.g. because the
+// service is unavailable, in which case the result is zero.
+func (oc *OtpClient) ValidateOtpWithResult(ctx context.Context, userId int, token string, opts ...RequestOption) (ValidateResult, error) {
+	outcome, err := oc.validateOtpWithOutcome(ctx, "ValidateOtpWithResult", userId, token, opts)
+	switch {
+	case err != nil:
+		return 0, err
+	case outcome.Valid:
+		return ValidateResultValid, nil
+	case outcome.RateLimited:
+		return ValidateResultRateLimited, nil
+	default:
+		return ValidateResultInvalid, nil
+	}
+}
+
+// PeekOtpValid reports whether a token is currently valid without consuming
+// it, so it can still be submitted through ValidateOtp afterwards. A token
+// rejected by the server is reported as false with a nil error.
+func (oc *OtpClient) PeekOtpValid(ctx context.Context, userId int, token string, opts ...RequestOption) (bool, error) {
+	req := http_client.HttpRequestWithBody[ValidateOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/otp/validate"),
+			QueryParameters: map[string]string{
+				"consume": "false",
+			},
+		},
+		Body: ValidateOtpRequest{
+			UserId: userId,
+			Token:  token,
+		},
+	}
+
+	err := postRequestWithBodyWithNoContent[ValidateOtpRequest](ctx, oc, "PeekOtpValid", req, opts)
+	if err != nil {
+		var badRequestErr *BadRequestError
+		if errors.As(err, &badRequestErr) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+type CreateUserHotpResponse struct {
+	Secret  string `json:"secret"`
+	AuthUrl string `json:"auth_url"`
+	Counter uint64 `json:"counter"`
+}
+
+func (oc *OtpClient) CreateUserHotp(ctx context.Context, userId int, opts ...RequestOption) (CreateUserHotpResponse, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/hotp", userId)),
+	}
+
+	resp, err := withIdempotencyCache(ctx, oc, "CreateUserHotp", opts, func() (CreateUserHotpResponse, error) {
+		return postRequest[CreateUserHotpResponse](ctx, oc, "CreateUserHotp", req, opts)
+	})
+	if err != nil {
+		return CreateUserHotpResponse{}, err
+	}
+
+	return resp, nil
+}
+
+type ValidateHotpRequest struct {
+	UserId  int    `json:"user_id"`
+	Token   string `json:"token"`
+	Counter uint64 `json:"counter"`
+}
+
+// ValidateHotp validates a counter-based token for a login. A successfully
+// validated token is consumed by the server and cannot be used again.
+func (oc *OtpClient) ValidateHotp(ctx context.Context, userId int, token string, counter uint64, opts ...RequestOption) error {
+	req := http_client.HttpRequestWithBody[ValidateHotpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/hotp/validate"),
+		},
+		Body: ValidateHotpRequest{
+			UserId:  userId,
+			Token:   token,
+			Counter: counter,
+		},
+	}
+
+	err := postRequestWithBodyWithNoContent[ValidateHotpRequest](ctx, oc, "ValidateHotp", req, opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type BatchValidateResult struct {
+	UserId int
+	Valid  bool
+	// Err describes why the token was rejected, it is nil for valid tokens.
+	Err error
+}
+
+type batchValidateItemResponse struct {
+	UserId  int    `json:"user_id"`
+	Valid   bool   `json:"valid"`
+	Problem string `json:"problem"`
+}
+
+// BatchValidateOtp validates the tokens of several users in a single round
+// trip. Results are returned in the order of items. Like ValidateOtp,
+// successfully validated tokens are consumed.
+func (oc *OtpClient) BatchValidateOtp(ctx context.Context, items []ValidateOtpRequest, opts ...RequestOption) ([]BatchValidateResult, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	req := http_client.HttpRequestWithBody[[]ValidateOtpRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/otp/validate/batch"),
+		},
+		Body: items,
+	}
+
+	resp, err := postRequestWithBody[[]ValidateOtpRequest, []batchValidateItemResponse](ctx, oc, "BatchValidateOtp", req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) != len(items) {
+		return nil, &BatchSizeMismatchError{len(items), len(resp)}
+	}
+
+	results := make([]BatchValidateResult, len(resp))
+	for i, item := range resp {
+		results[i] = BatchValidateResult{
+			UserId: items[i].UserId,
+			Valid:  item.Valid,
+		}
+
+		if !item.Valid {
+			results[i].Err = &BadRequestError{item.Problem}
+		}
+	}
+
+	return results, nil
+}
+
+// MigrateUserOtpToTotp converts the user's HOTP enrollment to TOTP and returns
+// the new secret. A ConflictError is returned if the user is already on TOTP,
+// and a NotFoundError if they have no OTP at all. The migration may invalidate
+// the user's existing recovery codes.
+func (oc *OtpClient) MigrateUserOtpToTotp(ctx context.Context, userId int, opts ...RequestOption) (CreateUserOtpResponse, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/migrate-to-totp", userId)),
+	}
+
+	resp, err := postRequest[CreateUserOtpResponse](ctx, oc, "MigrateUserOtpToTotp", req, opts)
+	if err != nil {
+		return CreateUserOtpResponse{}, err
+	}
+
+	return resp, nil
+}
+
+type GetRecoveryCodesQuotaResponse struct {
+	Allowed   bool `json:"allowed"`
+	Remaining int  `json:"remaining"`
+}
+
+// CanRegenerateRecoveryCodes reports whether the user may regenerate their
+// recovery codes, and how many regenerations they have left. An
+// UnsupportedEndpointError is returned by servers without regeneration quotas,
+// and a NotFoundError if the user has no OTP.
+func (oc *OtpClient) CanRegenerateRecoveryCodes(ctx context.Context, userId int, opts ...RequestOption) (bool, int, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/recovery-codes/quota", userId)),
+		// The server explains why a user was not found, unlike a 404 for the
+		// route itself.
+		ParseNotFoundBody: true,
+	}
+
+	resp, err := getRequest[GetRecoveryCodesQuotaResponse](ctx, oc, "CanRegenerateRecoveryCodes", req, opts)
+	if err != nil {
+		return false, 0, asUnsupportedRoute(err, "recovery code quotas")
+	}
+
+	return resp.Allowed, resp.Remaining, nil
+}
+
+type RegenerateRecoveryCodesResponse struct {
+	Codes               []string `json:"codes"`
+	OldCodesInvalidated bool     `json:"old_codes_invalidated"`
+}
+
+// RegenerateUserOtpRecoveryCodesStrict regenerates the user's recovery codes,
+// only returning the new codes once the server confirms that the old ones
+// were invalidated. ErrOldRecoveryCodesActive is returned otherwise, and the
+// new codes must not be shown to the user.
+func (oc *OtpClient) RegenerateUserOtpRecoveryCodesStrict(ctx context.Context, userId int, opts ...RequestOption) ([]string, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/recovery-codes/regenerate", userId)),
+	}
+
+	resp, err := postRequest[RegenerateRecoveryCodesResponse](ctx, oc, "RegenerateUserOtpRecoveryCodesStrict", req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.OldCodesInvalidated {
+		return nil, ErrOldRecoveryCodesActive
+	}
+
+	return resp.Codes, nil
+}
+
+type CheckRecoveryCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type CheckRecoveryCodeResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// IsRecoveryCodeValid reports whether the code is one of the user's unused
+// recovery codes, without redeeming it. A NotFoundError is returned if the user
+// has no OTP configured.
+func (oc *OtpClient) IsRecoveryCodeValid(ctx context.Context, userId int, code string, opts ...RequestOption) (bool, error) {
+	req := http_client.HttpRequestWithBody[CheckRecoveryCodeRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/recovery-codes/check", userId)),
+		},
+		Body: CheckRecoveryCodeRequest{
+			Code: code,
+		},
+	}
+
+	resp, err := postRequestWithBody[CheckRecoveryCodeRequest, CheckRecoveryCodeResponse](ctx, oc, "IsRecoveryCodeValid", req, opts)
+	if err != nil {
+		return false, err
+	}
+
+	return resp.Valid, nil
+}
+
+type VerifyRecoveryCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// VerifyRecoveryCode redeems one of the user's recovery codes in place of a
+// token, e.g. for a login after they lost their authenticator. A verified
+// code is consumed by the server and cannot be used again.
+func (oc *OtpClient) VerifyRecoveryCode(ctx context.Context, userId int, code string, opts ...RequestOption) error {
+	req := http_client.HttpRequestWithBody[VerifyRecoveryCodeRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/recovery-codes/verify", userId)),
+		},
+		Body: VerifyRecoveryCodeRequest{
+			Code: code,
+		},
+	}
+
+	err := postRequestWithBodyWithNoContent[VerifyRecoveryCodeRequest](ctx, oc, "VerifyRecoveryCode", req, opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type GenerateRecoveryCodesResponse struct {
+	Codes []string `json:"codes"`
+}
+
+// GenerateRecoveryCodes generates the user's recovery codes, returning them in
+// plaintext. The server does not return them again, so they have to be shown
+// to the user right away. A ConflictError is returned if the user already has
+// recovery codes; use RegenerateUserOtpRecoveryCodesStrict to replace them.
+func (oc *OtpClient) GenerateRecoveryCodes(ctx context.Context, userId int, opts ...RequestOption) ([]string, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/recovery-codes/generate", userId)),
+	}
+
+	resp, err := postRequest[GenerateRecoveryCodesResponse](ctx, oc, "GenerateRecoveryCodes", req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Codes, nil
+}
+
+type GetRecoveryCodeCountResponse struct {
+	Remaining int `json:"remaining"`
+}
+
+// CountRemainingRecoveryCodes returns how many unused recovery codes the user
+// has left, e.g. to suggest regenerating them when few remain. If the user has
+// no OTP configured, the error matches ErrOtpNotConfigured as well as
+// ErrNotFound.
+func (oc *OtpClient) CountRemainingRecoveryCodes(ctx context.Context, userId int, opts ...RequestOption) (int, error) {
+	counts, errs := oc.getRecoveryCodeCounts(ctx, "CountRemainingRecoveryCodes", []int{userId}, opts)
+	if err := errs[userId]; err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return 0, fmt.Errorf("%w: %w", ErrOtpNotConfigured, err)
+		}
+
+		return 0, err
+	}
+
+	return counts[userId], nil
+}
+
+// GetRecoveryCodeCounts returns how many unused recovery codes each of the
+// users has left. Users whose count could not be fetched are reported in the
+// error map instead, with a NotFoundError for users without OTP.
+func (oc *OtpClient) GetRecoveryCodeCounts(ctx context.Context, userIds []int, opts ...RequestOption) (map[int]int, map[int]error) {
+	return oc.getRecoveryCodeCounts(ctx, "GetRecoveryCodeCounts", userIds, opts)
+}
+
+func (oc *OtpClient) getRecoveryCodeCounts(ctx context.Context, op string, userIds []int, opts []RequestOption) (map[int]int, map[int]error) {
+	counts := make(map[int]int)
+	errs := make(map[int]error)
+
+	opts = fanOutRequestOptions(opts, len(userIds))
+
+	var mu sync.Mutex
+	forEachUser(userIds, oc.batchWorkers(), func(userId int) {
+		req := http_client.HttpRequest{
+			Url: oc.endpoint(fmt.Sprintf("/users/%d/otp/recovery-codes/count", userId)),
+		}
+
+		resp, err := getRequest[GetRecoveryCodeCountResponse](ctx, oc, op, req, opts)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			errs[userId] = err
+			return
+		}
+
+		counts[userId] = resp.Remaining
+	})
+
+	return counts, errs
+}
+
 type GetRememberedDeviceResponse struct {
 	UserId    int   `json:"user_id"`
 	ExpiresAt int64 `json:"expires_at"`
 }
 
-func (oc *OtpClient) GetRememberedDevice(id string) (GetRememberedDeviceResponse, error) {
+func (oc *OtpClient) GetRememberedDevice(ctx context.Context, id string, opts ...RequestOption) (GetRememberedDeviceResponse, error) {
 	req := http_client.HttpRequest{
-		Url: oc.BaseUrl + fmt.Sprintf("/remembered-devices/%s", id),
+		Url: oc.endpoint("/remembered-devices/" + url.PathEscape(id)),
 	}
 
-	resp, err := getRequest[GetRememberedDeviceResponse](oc, req)
+	resp, err := getRequest[GetRememberedDeviceResponse](ctx, oc, "GetRememberedDevice", req, opts)
 	if err != nil {
 		return GetRememberedDeviceResponse{}, err
 	}
@@ -257,17 +1452,17 @@ type CreateRememberedDeviceResponse struct {
 	ExpiresAt int64  `json:"expires_at"`
 }
 
-func (oc *OtpClient) CreateRememberedDevice(userId int) (CreateRememberedDeviceResponse, error) {
+func (oc *OtpClient) CreateRememberedDevice(ctx context.Context, userId int, opts ...RequestOption) (CreateRememberedDeviceResponse, error) {
 	req := http_client.HttpRequestWithBody[CreateRememberedDeviceRequest]{
 		HttpRequest: http_client.HttpRequest{
-			Url: oc.BaseUrl + "/remembered-devices",
+			Url: oc.endpoint("/remembered-devices"),
 		},
 		Body: CreateRememberedDeviceRequest{
 			UserId: userId,
 		},
 	}
 
-	resp, err := postRequestWithBody[CreateRememberedDeviceRequest, CreateRememberedDeviceResponse](oc, req)
+	resp, err := postRequestWithBody[CreateRememberedDeviceRequest, CreateRememberedDeviceResponse](ctx, oc, "CreateRememberedDevice", req, opts)
 	if err != nil {
 		return CreateRememberedDeviceResponse{}, nil
 	}
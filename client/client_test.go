@@ -0,0 +1,308 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serve starts a server answering every request with the given status and
+// JSON body.
+func serve(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestValidateOtpWithOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   ValidateOutcome
+	}{
+		{"valid", http.StatusOK, `{"remaining_attempts": 5}`, ValidateOutcome{Valid: true, RemainingAttempts: 5}},
+		{"invalid", http.StatusBadRequest, `{"remaining_attempts": 2}`, ValidateOutcome{RemainingAttempts: 2}},
+		{"invalid without body", http.StatusBadRequest, ``, ValidateOutcome{}},
+		{"invalid with problem body", http.StatusBadRequest, `"invalid token"`, ValidateOutcome{}},
+		{"locked", http.StatusTooManyRequests, `{"locked_until": 1700000000}`, ValidateOutcome{RateLimited: true, LockedUntil: time.Unix(1700000000, 0)}},
+		{"locked without body", http.StatusTooManyRequests, ``, ValidateOutcome{RateLimited: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, tt.status, tt.body)
+
+			got, err := New(srv.URL, "secret").ValidateOtpWithOutcome(context.Background(), 1, "123456")
+			if err != nil {
+				t.Fatalf("ValidateOtpWithOutcome() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ValidateOtpWithOutcome() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOtpWithResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    ValidateResult
+		wantErr bool
+	}{
+		{"valid", http.StatusNoContent, ``, ValidateResultValid, false},
+		{"invalid", http.StatusBadRequest, `{"remaining_attempts": 2}`, ValidateResultInvalid, false},
+		{"rate limited", http.StatusTooManyRequests, `{"locked_until": 1700000000}`, ValidateResultRateLimited, false},
+		{"rate limited without body", http.StatusTooManyRequests, ``, ValidateResultRateLimited, false},
+		{"unavailable", http.StatusServiceUnavailable, ``, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, tt.status, tt.body)
+
+			got, err := New(srv.URL, "secret").ValidateOtpWithResult(context.Background(), 1, "123456")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateOtpWithResult() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ValidateOtpWithResult() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyOtpWithResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    VerifyOtpResponse
+		wantErr bool
+	}{
+		{"verified", http.StatusNoContent, ``, VerifyOtpResponse{}, false},
+		{"verified with details", http.StatusOK, `{"remaining_attempts": 5}`, VerifyOtpResponse{RemainingAttempts: 5}, false},
+		{"rejected", http.StatusBadRequest, `{"remaining_attempts": 2}`, VerifyOtpResponse{RemainingAttempts: 2}, true},
+		{"locked", http.StatusTooManyRequests, `{"locked_until": 1700000000}`, VerifyOtpResponse{LockedUntil: time.Unix(1700000000, 0)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, tt.status, tt.body)
+
+			got, err := New(srv.URL, "secret").VerifyOtpWithResult(context.Background(), 1, "123456")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyOtpWithResult() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("VerifyOtpWithResult() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyOtpWithoutResponseBody(t *testing.T) {
+	calls := []struct {
+		name    string
+		call    func(oc *OtpClient) error
+		wantErr error
+	}{
+		{"VerifyOtpForSession", func(oc *OtpClient) error {
+			_, err := oc.VerifyOtpForSession(context.Background(), 1, "123456")
+			return err
+		}, ErrNoSessionToken},
+		{"VerifyOtpTrustDevice", func(oc *OtpClient) error {
+			_, err := oc.VerifyOtpTrustDevice(context.Background(), 1, "123456", "laptop")
+			return err
+		}, ErrNoDeviceToken},
+		{"VerifyOtpWithNonce", func(oc *OtpClient) error {
+			_, err := oc.VerifyOtpWithNonce(context.Background(), 1, "123456", "nonce")
+			return err
+		}, &NonceMismatchError{Sent: "nonce"}},
+	}
+
+	for _, body := range []string{``, `{}`} {
+		for _, c := range calls {
+			t.Run(fmt.Sprintf("%s %q", c.name, body), func(t *testing.T) {
+				srv := serve(t, http.StatusOK, body)
+
+				err := c.call(New(srv.URL, "secret"))
+				if !reflect.DeepEqual(err, c.wantErr) {
+					t.Errorf("%s() error = %v, want %v", c.name, err, c.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestNotFoundProblemParity(t *testing.T) {
+	calls := []struct {
+		name string
+		call func(oc *OtpClient) error
+	}{
+		{"GET", func(oc *OtpClient) error {
+			_, err := oc.GetUserOtp(context.Background(), 1)
+			return err
+		}},
+		{"POST", func(oc *OtpClient) error {
+			return oc.VerifyOtp(context.Background(), 1, "123456")
+		}},
+		{"DELETE", func(oc *OtpClient) error {
+			return oc.DeleteUserOtp(context.Background(), 1)
+		}},
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want NotFoundError
+	}{
+		{"default", nil, NotFoundError{}},
+		{"with problem", []Option{WithNotFoundProblem()}, NotFoundError{Problem: "otp not enabled"}},
+	}
+
+	for _, tt := range tests {
+		for _, c := range calls {
+			t.Run(tt.name+" "+c.name, func(t *testing.T) {
+				srv := serve(t, http.StatusNotFound, `{"problem": "otp not enabled"}`)
+
+				err := c.call(New(srv.URL, "secret", tt.opts...))
+
+				var notFoundErr *NotFoundError
+				if !errors.As(err, &notFoundErr) {
+					t.Fatalf("error = %v, want a NotFoundError", err)
+				}
+
+				if *notFoundErr != tt.want {
+					t.Errorf("error = %+v, want %+v", *notFoundErr, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestEndpointPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		want     string
+	}{
+		{"no path", "", "/users/1/otp"},
+		{"trailing slash", "/", "/users/1/otp"},
+		{"sub-path", "/api/v1", "/api/v1/users/1/otp"},
+		{"sub-path with trailing slash", "/api/v1/", "/api/v1/users/1/otp"},
+		{"sub-path with double slash", "/api//v1//", "/api/v1/users/1/otp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"verified": true}`))
+			}))
+			defer srv.Close()
+
+			baseUrl := srv.URL + tt.basePath
+			u, err := url.Parse(baseUrl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fromURL, err := NewOtpClientFromURL(u, "secret")
+			if err != nil {
+				t.Fatalf("NewOtpClientFromURL() error = %v", err)
+			}
+
+			clients := map[string]*OtpClient{
+				"New":                 New(baseUrl, "secret"),
+				"NewOtpClientFromURL": fromURL,
+			}
+
+			for constructor, oc := range clients {
+				got = ""
+				if _, err := oc.GetUserOtp(context.Background(), 1); err != nil {
+					t.Fatalf("%s: GetUserOtp() error = %v", constructor, err)
+				}
+
+				if got != tt.want {
+					t.Errorf("%s: requested path = %q, want %q", constructor, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetSecretConcurrently(t *testing.T) {
+	secrets := []string{"secret-0", "secret-1", "secret-2"}
+
+	var mu sync.Mutex
+	received := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.Header.Get(DefaultAuthHeader)] = true
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": true}`))
+	}))
+	defer srv.Close()
+
+	// Run with -race: requests read the secret while it is replaced.
+	oc := New(srv.URL, secrets[0])
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				if _, err := oc.GetUserOtp(context.Background(), 1); err != nil {
+					t.Errorf("GetUserOtp() error = %v", err)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 30; i++ {
+		oc.SetSecret(secrets[i%len(secrets)])
+	}
+	wg.Wait()
+
+	for secret := range received {
+		if !slices.Contains(secrets, secret) {
+			t.Errorf("received secret %q, want one of %q", secret, secrets)
+		}
+	}
+
+	oc.SetSecret("rotated")
+	if _, err := oc.GetUserOtp(context.Background(), 1); err != nil {
+		t.Fatalf("GetUserOtp() error = %v", err)
+	}
+
+	if !received["rotated"] {
+		t.Error("request after SetSecret did not use the new secret")
+	}
+}
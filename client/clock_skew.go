@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// totpPeriod is the time step used by the OTP service for TOTP tokens.
+const totpPeriod = 30 * time.Second
+
+var ErrNoServerDate = errors.New("server response has no valid Date header")
+
+// DetectClockSkew compares the Date header of a response from the OTP
+// service's health endpoint with the local clock and returns how far the
+// server is ahead of the client. Any response is accepted, regardless of its
+// status code.
+func (oc *OtpClient) DetectClockSkew(ctx context.Context, opts ...RequestOption) (time.Duration, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint("/health"),
+	}
+
+	if err := prepareRequest(ctx, oc, &req, opts); err != nil {
+		return 0, err
+	}
+
+	// Only the last attempt's Date header is compared, so time each attempt
+	// rather than the whole call with its retries and waits between them.
+	var resp http_client.HttpResponse
+	var start time.Time
+	var elapsed time.Duration
+	err := execute(ctx, oc, "DetectClockSkew", http.MethodGet, req, opts, func(ctx context.Context) (http_client.HttpResponse, error) {
+		start = time.Now()
+
+		var err error
+		resp, err = http_client.GetWithNoContent(ctx, oc.client(), req)
+		elapsed = time.Since(start)
+		return resp, err
+	})
+
+	// The headers are needed whatever the status, so error responses are not
+	// turned into errors.
+	if resp.StatusCode == 0 {
+		return 0, err
+	}
+
+	serverDate, err := http.ParseTime(http.Header(resp.Headers).Get("Date"))
+	if err != nil {
+		return 0, ErrNoServerDate
+	}
+
+	// The server stamps the Date header somewhere between sending the request
+	// and receiving the response, so compare it to the midpoint.
+	skew := serverDate.Sub(start.Add(elapsed / 2))
+
+	if absDuration(skew) > totpPeriod/2 && oc.Logger != nil {
+		oc.Logger.Warn(
+			"clock skew with otp service exceeds half a totp period",
+			"skew", skew,
+			"period", totpPeriod,
+		)
+	}
+
+	return skew, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDetectClockSkew(t *testing.T) {
+	const ahead = time.Hour
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			t.Errorf("path = %s, want /health", r.URL.Path)
+		}
+
+		w.Header().Set("Date", time.Now().Add(ahead).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	observations := 0
+	oc := New(srv.URL, "secret", WithMetricsRecorder(MetricsRecorderFunc(func(op string, statusCode int, duration time.Duration, err error) {
+		observations++
+	})))
+
+	skew, err := oc.DetectClockSkew(context.Background())
+	if err != nil {
+		t.Fatalf("DetectClockSkew() error = %v", err)
+	}
+
+	if absDuration(skew-ahead) > 2*time.Second {
+		t.Errorf("DetectClockSkew() = %v, want about %v", skew, ahead)
+	}
+
+	if observations != 1 {
+		t.Errorf("observations = %d, want 1", observations)
+	}
+}
+
+func TestDetectClockSkewTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	_, err := New(srv.URL, "secret", WithTimeout(50*time.Millisecond)).DetectClockSkew(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DetectClockSkew() error = %v, want a deadline error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("DetectClockSkew() took %v despite a 50ms timeout", elapsed)
+	}
+}
+
+func TestDetectClockSkewAfterRetry(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// Fail on a whole second, so that the retry one second later is
+			// answered with a Date header accurate to the millisecond.
+			time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+
+	skew, err := New(srv.URL, "secret", WithRetryPolicy(2, time.Millisecond)).DetectClockSkew(context.Background())
+	if err != nil {
+		t.Fatalf("DetectClockSkew() error = %v", err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", attempts)
+	}
+
+	// Timing the failed attempt and the wait for the retry would put the
+	// skew at least half a second off.
+	if absDuration(skew) > 250*time.Millisecond {
+		t.Errorf("DetectClockSkew() = %v, want about 0", skew)
+	}
+}
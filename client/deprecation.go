@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// DeprecationNotice describes the deprecation signals the server attached to
+// the response of an operation.
+type DeprecationNotice struct {
+	Operation string
+	// Deprecation is the raw value of the Deprecation header, if any.
+	Deprecation string
+	Warnings    []string
+	// Sunset is when the endpoint is planned to be removed, or the zero time
+	// if the server did not send a Sunset header.
+	Sunset time.Time
+}
+
+// WithDeprecationNotify calls notify for every response carrying a
+// Deprecation, Warning or Sunset header. Such responses are also logged at the
+// warn level if the client has a Logger.
+func WithDeprecationNotify(notify func(DeprecationNotice)) Option {
+	return func(oc *OtpClient) {
+		oc.deprecationNotify = notify
+	}
+}
+
+func parseDeprecationNotice(op string, headers map[string][]string) (DeprecationNotice, bool) {
+	header := http.Header(headers)
+
+	notice := DeprecationNotice{
+		Operation:   op,
+		Deprecation: header.Get("Deprecation"),
+		Warnings:    header.Values("Warning"),
+	}
+
+	sunset := header.Get("Sunset")
+	if sunset != "" {
+		if sunsetTime, err := http.ParseTime(sunset); err == nil {
+			notice.Sunset = sunsetTime
+		}
+	}
+
+	if notice.Deprecation == "" && len(notice.Warnings) == 0 && sunset == "" {
+		return DeprecationNotice{}, false
+	}
+
+	return notice, true
+}
+
+func (oc *OtpClient) reportDeprecation(op string, resp http_client.HttpResponse) {
+	notice, ok := parseDeprecationNotice(op, resp.Headers)
+	if !ok {
+		return
+	}
+
+	if oc.Logger != nil {
+		attrs := []slog.Attr{
+			slog.String("operation", notice.Operation),
+			slog.String("deprecation", notice.Deprecation),
+			slog.Any("warnings", notice.Warnings),
+		}
+		if !notice.Sunset.IsZero() {
+			attrs = append(attrs, slog.Time("sunset", notice.Sunset))
+		}
+
+		oc.Logger.LogAttrs(context.Background(), slog.LevelWarn, "otp service endpoint is deprecated", attrs...)
+	}
+
+	if oc.deprecationNotify != nil {
+		oc.deprecationNotify(notice)
+	}
+}
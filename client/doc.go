@@ -0,0 +1,21 @@
+// Package client is a client for the OTP service.
+//
+//	import "github.com/osuAkatsuki/otp-service-client-go/client"
+//
+//	otpClient := client.New("https://otp.example.com", secret)
+//	otp, err := otpClient.GetUserOtp(ctx, userId)
+//
+// Every method making a request takes a context, which bounds the request
+// along with any retries and the waits between them.
+//
+// Clients are configured with options, all of which have defaults:
+//
+//	otpClient, err := client.NewOtpClientWithOptions("https://otp.example.com", secret,
+//		client.WithTimeout(5*time.Second),
+//		client.WithRetryPolicy(3, 100*time.Millisecond),
+//		client.WithUserAgent("my-service/1.0"),
+//	)
+//
+// Code using the client can be tested against a stubbed OTP service by
+// injecting a Doer with WithDoer, as its example shows.
+package client
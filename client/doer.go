@@ -0,0 +1,32 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// Doer sends the client's HTTP requests. *http.Client implements it, and
+// tests of code using the client can implement it to stub the OTP service.
+type Doer = http_client.Doer
+
+// DoerFunc adapts a function to a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithDoer sends the client's requests through doer instead of an
+// http.Client. Redirects are then left to doer, as are the options
+// configuring the client's transport.
+func WithDoer(doer Doer) Option {
+	return func(oc *OtpClient) {
+		if doer == nil {
+			oc.setConfigErr("doer is missing")
+			return
+		}
+
+		oc.doer = doer
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+type EnrollStep string
+
+const (
+	EnrollStepCreate        EnrollStep = "create"
+	EnrollStepVerify        EnrollStep = "verify"
+	EnrollStepRecoveryCodes EnrollStep = "recovery_codes"
+)
+
+// EnrollStepError is returned by EnrollUserOtp when one of its steps fails,
+// including when ctx expires during it.
+type EnrollStepError struct {
+	Step EnrollStep
+	Err  error
+}
+
+func (e *EnrollStepError) Error() string {
+	return fmt.Sprintf("otp enrollment failed at %s step: %s", e.Step, e.Err)
+}
+
+func (e *EnrollStepError) Unwrap() error {
+	return e.Err
+}
+
+type EnrollResult struct {
+	Otp           CreateUserOtpResponse
+	RecoveryCodes []string
+}
+
+// EnrollUserOtp enrolls a user in OTP by creating their secret, verifying the
+// token they entered for it, and generating their recovery codes. All steps
+// share ctx, so a deadline set with context.WithTimeout bounds the whole
+// enrollment rather than each step.
+func (oc *OtpClient) EnrollUserOtp(ctx context.Context, userId int, token string, opts ...RequestOption) (EnrollResult, error) {
+	otp, err := oc.CreateUserOtp(ctx, userId, opts...)
+	if err != nil {
+		return EnrollResult{}, &EnrollStepError{EnrollStepCreate, err}
+	}
+
+	err = oc.VerifySetupOtp(ctx, userId, token, opts...)
+	if err != nil {
+		return EnrollResult{}, &EnrollStepError{EnrollStepVerify, err}
+	}
+
+	// A new enrollment has no old codes which could remain active.
+	codes, err := oc.GenerateRecoveryCodes(ctx, userId, opts...)
+	if err != nil {
+		return EnrollResult{}, &EnrollStepError{EnrollStepRecoveryCodes, err}
+	}
+
+	return EnrollResult{
+		Otp:           otp,
+		RecoveryCodes: codes,
+	}, nil
+}
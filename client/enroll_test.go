@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEnrollUserOtp(t *testing.T) {
+	tests := []struct {
+		name      string
+		failPath  string
+		hangPath  string
+		wantStep  EnrollStep
+		wantCodes []string
+	}{
+		{"enrolled", "", "", "", []string{"code-1", "code-2"}},
+		{"create fails", "/users/1/otp", "", EnrollStepCreate, nil},
+		{"verify fails", "/otp/setup/verify", "", EnrollStepVerify, nil},
+		{"recovery codes fail", "/users/1/otp/recovery-codes/generate", "", EnrollStepRecoveryCodes, nil},
+		{"times out verifying", "", "/otp/setup/verify", EnrollStepVerify, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.Path {
+				case tt.hangPath:
+					// The client hanging up is only noticed once the body is read.
+					io.Copy(io.Discard, r.Body)
+					<-r.Context().Done()
+					return
+				case tt.failPath:
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				switch r.URL.Path {
+				case "/users/1/otp":
+					w.Write([]byte(`{"secret": "JBSWY3DPEHPK3PXP", "auth_url": "otpauth://totp/osu"}`))
+				case "/otp/setup/verify":
+					w.WriteHeader(http.StatusNoContent)
+				case "/users/1/otp/recovery-codes/generate":
+					// Unlike regenerating them, generating the first codes
+					// does not report whether old codes were invalidated.
+					w.Write([]byte(`{"codes": ["code-1", "code-2"]}`))
+				default:
+					t.Errorf("unexpected request to %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			result, err := New(srv.URL, "secret").EnrollUserOtp(ctx, 1, "123456")
+			if tt.wantStep == "" {
+				if err != nil {
+					t.Fatalf("EnrollUserOtp() error = %v", err)
+				}
+
+				if !slices.Equal(result.RecoveryCodes, tt.wantCodes) {
+					t.Errorf("EnrollUserOtp() codes = %v, want %v", result.RecoveryCodes, tt.wantCodes)
+				}
+
+				return
+			}
+
+			var stepErr *EnrollStepError
+			if !errors.As(err, &stepErr) || stepErr.Step != tt.wantStep {
+				t.Fatalf("EnrollUserOtp() error = %v, want a failure at the %s step", err, tt.wantStep)
+			}
+
+			if tt.hangPath != "" && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("EnrollUserOtp() error = %v, want a deadline error", err)
+			}
+		})
+	}
+}
@@ -1,11 +1,88 @@
 package client
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
 
-type NotFoundError struct{}
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// Every error returned for an error response of the corresponding category
+// matches one of these with errors.Is.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrBadRequest      = errors.New("bad request")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+var (
+	ErrNoSessionToken         = errors.New("server did not issue a session token")
+	ErrNoDeviceToken          = errors.New("server did not issue a trusted device token")
+	ErrOldRecoveryCodesActive = errors.New("server did not confirm that the old recovery codes were invalidated")
+	ErrOtpNotConfigured       = errors.New("user has no otp configured")
+)
+
+// APIError is the status code and problem of an error response from the OTP
+// service. Every error returned for an error response can be converted into
+// one with errors.As, regardless of its concrete type:
+//
+//	var apiErr *client.APIError
+//	if errors.As(err, &apiErr) {
+//		log.Printf("otp service responded %d: %s", apiErr.StatusCode(), apiErr.Problem())
+//	}
+type APIError struct {
+	statusCode int
+	problem    string
+}
+
+func (e *APIError) StatusCode() int {
+	return e.statusCode
+}
+
+func (e *APIError) Problem() string {
+	return e.problem
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("otp service responded with status %d: %s", e.statusCode, e.problem)
+}
+
+// asAPIError implements errors.As for the error types of error responses.
+func asAPIError(target any, statusCode int, problem string) bool {
+	apiErr, ok := target.(**APIError)
+	if !ok {
+		return false
+	}
+
+	*apiErr = &APIError{statusCode, problem}
+	return true
+}
+
+type NotFoundError struct {
+	// Problem is only set by clients created with WithNotFoundProblem.
+	Problem string
+}
 
 func (e *NotFoundError) Error() string {
-	return "not found"
+	if e.Problem == "" {
+		return "not found"
+	}
+
+	return fmt.Sprintf("not found: %s", e.Problem)
+}
+
+func (e *NotFoundError) As(target any) bool {
+	return asAPIError(target, http.StatusNotFound, e.Problem)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
 }
 
 type BadRequestError struct {
@@ -16,6 +93,14 @@ func (e *BadRequestError) Error() string {
 	return fmt.Sprintf("bad request: %s", e.Problem)
 }
 
+func (e *BadRequestError) As(target any) bool {
+	return asAPIError(target, http.StatusBadRequest, e.Problem)
+}
+
+func (e *BadRequestError) Is(target error) bool {
+	return target == ErrBadRequest
+}
+
 type ConflictError struct {
 	Problem string
 }
@@ -24,10 +109,220 @@ func (e *ConflictError) Error() string {
 	return fmt.Sprintf("conflict: %s", e.Problem)
 }
 
-type UnknownError struct {
+func (e *ConflictError) As(target any) bool {
+	return asAPIError(target, http.StatusConflict, e.Problem)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// UnauthorizedError is returned when the server rejected the client's secret,
+// which usually means it is misconfigured or was rotated.
+type UnauthorizedError struct {
+	Problem string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized: %s", e.Problem)
+}
+
+func (e *UnauthorizedError) As(target any) bool {
+	return asAPIError(target, http.StatusUnauthorized, e.Problem)
+}
+
+func (e *UnauthorizedError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// ForbiddenError is returned when the client's secret is not allowed to make
+// a request.
+type ForbiddenError struct {
 	Problem string
 }
 
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("forbidden: %s", e.Problem)
+}
+
+func (e *ForbiddenError) As(target any) bool {
+	return asAPIError(target, http.StatusForbidden, e.Problem)
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// TooManyRequestsError is returned when the server rate limited a request.
+// RetryAfter is how long the server asked the client to wait before trying
+// again, or zero if it did not say.
+type TooManyRequestsError struct {
+	Problem    string
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+	if e.RetryAfter == 0 {
+		return fmt.Sprintf("too many requests: %s", e.Problem)
+	}
+
+	return fmt.Sprintf("too many requests, retry after %s: %s", e.RetryAfter, e.Problem)
+}
+
+func (e *TooManyRequestsError) As(target any) bool {
+	return asAPIError(target, http.StatusTooManyRequests, e.Problem)
+}
+
+func (e *TooManyRequestsError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
+
+type UnknownError struct {
+	Problem    string
+	StatusCode int
+}
+
 func (e *UnknownError) Error() string {
+	if e.Problem == "" {
+		return fmt.Sprintf("unknown error: status %d", e.StatusCode)
+	}
+
 	return fmt.Sprintf("unknown error: %s", e.Problem)
 }
+
+func (e *UnknownError) As(target any) bool {
+	return asAPIError(target, e.StatusCode, e.Problem)
+}
+
+// ServiceUnhealthyError is returned by HealthCheck when the OTP service
+// responded to its health check with an error status.
+type ServiceUnhealthyError struct {
+	StatusCode int
+	Problem    string
+}
+
+func (e *ServiceUnhealthyError) Error() string {
+	return fmt.Sprintf("otp service is unhealthy, responding with status %d: %s", e.StatusCode, e.Problem)
+}
+
+func (e *ServiceUnhealthyError) As(target any) bool {
+	return asAPIError(target, e.StatusCode, e.Problem)
+}
+
+// ConfigurationError is returned when the client was constructed with invalid
+// options.
+type ConfigurationError struct {
+	Problem string
+}
+
+func (e *ConfigurationError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", e.Problem)
+}
+
+// DNSError is returned when the OTP service hostname could not be resolved.
+type DNSError struct {
+	Err *net.DNSError
+}
+
+func (e *DNSError) Error() string {
+	return fmt.Sprintf("dns resolution failed: %s", e.Err)
+}
+
+func (e *DNSError) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether the lookup may succeed if retried. A host that
+// does not exist (NXDOMAIN) is permanent, while a server failure (SERVFAIL)
+// or timeout is temporary.
+func (e *DNSError) Temporary() bool {
+	if e.Err.IsNotFound {
+		return false
+	}
+
+	return e.Err.Temporary()
+}
+
+// BatchSizeMismatchError is returned when the server answers a batch request
+// with a different number of results than items were sent.
+type BatchSizeMismatchError struct {
+	Requested int
+	Received  int
+}
+
+func (e *BatchSizeMismatchError) Error() string {
+	return fmt.Sprintf("batch size mismatch: requested %d, received %d", e.Requested, e.Received)
+}
+
+// ResponseDecodingError is returned when the body of a successful response
+// could not be decoded, e.g. because it is malformed or, with WithStrictJSON,
+// has unknown fields.
+type ResponseDecodingError = http_client.ResponseDecodingError
+
+// ResponseTooLargeError is returned when a response body exceeds the
+// configured size limit.
+type ResponseTooLargeError = http_client.ResponseTooLargeError
+
+// UnsupportedEndpointError is returned when the OTP service does not implement
+// an endpoint, typically because it predates it. It matches
+// errors.ErrUnsupported.
+type UnsupportedEndpointError struct {
+	Endpoint string
+}
+
+func (e *UnsupportedEndpointError) Error() string {
+	return fmt.Sprintf("otp service does not support %s", e.Endpoint)
+}
+
+func (e *UnsupportedEndpointError) Is(target error) bool {
+	return target == errors.ErrUnsupported
+}
+
+// asUnsupportedEndpoint converts the error of a request to an endpoint that
+// older servers lack into an UnsupportedEndpointError, if the server rejected
+// it as unimplemented.
+func asUnsupportedEndpoint(err error, endpoint string) error {
+	var unknownErr *UnknownError
+	if !errors.As(err, &unknownErr) {
+		return err
+	}
+
+	switch unknownErr.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return &UnsupportedEndpointError{endpoint}
+	default:
+		return err
+	}
+}
+
+// UnexpectedContentTypeError is returned when a successful response has a
+// body which is not JSON, such as an HTML page served by a proxy.
+type UnexpectedContentTypeError = http_client.UnexpectedContentTypeError
+
+// RequestEncodingError is returned when a request body could not be encoded
+// as JSON. Nothing was sent to the server in that case.
+type RequestEncodingError = http_client.RequestEncodingError
+
+// NonceMismatchError is returned when the server echoes a different nonce than
+// the one sent with a request, which may indicate a replayed response.
+type NonceMismatchError struct {
+	Sent     string
+	Received string
+}
+
+func (e *NonceMismatchError) Error() string {
+	return fmt.Sprintf("nonce mismatch: sent %q, received %q", e.Sent, e.Received)
+}
+
+// asUnsupportedRoute is like asUnsupportedEndpoint, but also treats a 404
+// without a problem as unimplemented. It suits endpoints which do not answer
+// with such a 404 themselves, e.g. bulk endpoints reporting missing users in
+// their body, so that it comes from a server lacking the route itself.
+func asUnsupportedRoute(err error, endpoint string) error {
+	var notFoundErr *NotFoundError
+	if errors.As(err, &notFoundErr) && notFoundErr.Problem == "" {
+		return &UnsupportedEndpointError{endpoint}
+	}
+
+	return asUnsupportedEndpoint(err, endpoint)
+}
@@ -0,0 +1,38 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/osuAkatsuki/otp-service-client-go/client"
+)
+
+// Code using the client can be tested against a stubbed OTP service by
+// injecting a Doer, here one answering every VerifyOtp call with a conflict.
+func ExampleWithDoer() {
+	conflict := client.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		rec.WriteHeader(http.StatusConflict)
+		rec.WriteString(`{"problem": "otp already verified"}`)
+		return rec.Result(), nil
+	})
+
+	otpClient, err := client.NewOtpClientWithOptions("https://otp.example.com", "secret",
+		client.WithDoer(conflict),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	err = otpClient.VerifyOtp(context.Background(), 1, "123456")
+
+	var conflictErr *client.ConflictError
+	if errors.As(err, &conflictErr) {
+		fmt.Println(conflictErr.Problem)
+	}
+	// Output: otp already verified
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// IdempotencyKeyHeader carries the key identifying a logical create
+// operation, which lets the server deduplicate creates that are retried
+// after the client gave up on a response that was in fact successful.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// setIdempotencyKey attaches the key given with WithIdempotencyKey to the
+// request, or a random one if there was none. The key is set once per call, so
+// it is shared by all of the call's attempts.
+func setIdempotencyKey(request *http_client.HttpRequest, opts []RequestOption) {
+	key := applyRequestOptions(opts).idempotencyKey
+	if key == "" {
+		key = newUUID()
+	}
+
+	request.SetHeader(IdempotencyKeyHeader, key)
+}
+
+// hasIdempotencyKey reports whether the server can deduplicate the request,
+// making it safe to retry even though it is not idempotent by itself.
+func hasIdempotencyKey(request http_client.HttpRequest) bool {
+	return request.SingularHeaders[IdempotencyKeyHeader] != ""
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var uuid [16]byte
+	// crypto/rand.Read never returns an error.
+	_, _ = rand.Read(uuid[:])
+
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// WithIdempotencyCache remembers the results of successful creates made with
+// WithIdempotencyKey for ttl, returning them again for calls repeating the key
+// without making another request. This guards against duplicate creates when
+// a whole operation is retried, even if the server does not deduplicate them
+// itself. At most maxEntries results are kept, evicting the oldest first.
+func WithIdempotencyCache(ttl time.Duration, maxEntries int) Option {
+	return func(oc *OtpClient) {
+		if ttl <= 0 || maxEntries <= 0 {
+			oc.setConfigErr("idempotency cache ttl and size must be positive")
+			return
+		}
+
+		oc.idempotencyCache = &idempotencyCache{
+			ttl:        ttl,
+			maxEntries: maxEntries,
+			entries:    make(map[string]*idempotencyEntry),
+			order:      list.New(),
+		}
+	}
+}
+
+type idempotencyCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+	// order holds the keys of entries, oldest first.
+	order *list.List
+}
+
+type idempotencyEntry struct {
+	done      chan struct{}
+	value     any
+	err       error
+	expiresAt time.Time
+	element   *list.Element
+}
+
+func (c *idempotencyCache) do(ctx context.Context, key string, fn func() (any, error)) (any, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && entry.expiresAt.IsZero() {
+		// Another call with the same key is in flight.
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+			return entry.value, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+
+	if ok {
+		c.remove(key, entry)
+	}
+
+	entry = &idempotencyEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	entry.element = c.order.PushBack(key)
+	c.evict()
+	c.mu.Unlock()
+
+	entry.value, entry.err = fn()
+
+	c.mu.Lock()
+	if entry.err != nil {
+		// Failed calls are not remembered, so that they can be retried.
+		c.remove(key, entry)
+	} else {
+		entry.expiresAt = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+
+	close(entry.done)
+	return entry.value, entry.err
+}
+
+func (c *idempotencyCache) remove(key string, entry *idempotencyEntry) {
+	if c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+	c.order.Remove(entry.element)
+}
+
+func (c *idempotencyCache) evict() {
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Front()
+		key := oldest.Value.(string)
+		c.remove(key, c.entries[key])
+	}
+}
+
+// withIdempotencyCache runs fn through the client's idempotency cache if the
+// call carries an idempotency key, and directly otherwise.
+func withIdempotencyCache[T any](ctx context.Context, oc *OtpClient, op string, opts []RequestOption, fn func() (T, error)) (T, error) {
+	key := applyRequestOptions(opts).idempotencyKey
+	if oc.idempotencyCache == nil || key == "" {
+		return fn()
+	}
+
+	value, err := oc.idempotencyCache.do(ctx, op+":"+key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		var def T
+		return def, err
+	}
+
+	return value.(T), nil
+}
@@ -0,0 +1,40 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/osuAkatsuki/otp-service-client-go/client"
+)
+
+// TestImportable uses the client from outside the package, as another module
+// would, so that it keeps compiling as a library.
+func TestImportable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail": "user not found"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	// Callers holding the value returned by NewOtpClient must keep compiling,
+	// as must those building the client from its exported fields.
+	byValue := client.NewOtpClient(srv.URL, "secret")
+	clients := map[string]*client.OtpClient{
+		"New":          client.New(srv.URL, "secret"),
+		"NewOtpClient": &byValue,
+		"literal":      {BaseUrl: srv.URL, Secret: "secret"},
+	}
+
+	for constructor, oc := range clients {
+		_, err := oc.GetUserOtp(context.Background(), 1)
+
+		var notFound *client.NotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("%s: GetUserOtp() error = %v, want a NotFoundError", constructor, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// WithLogSampleRate logs only the given fraction of successful requests, e.g.
+// 0.01 for one in a hundred. Failed requests, including those answered with an
+// error status, are always logged. Spans implementing LogSampledSpan are told
+// whether their call was logged.
+func WithLogSampleRate(rate float64) Option {
+	return func(oc *OtpClient) {
+		if rate < 0 || rate > 1 {
+			oc.setConfigErr("log sample rate must be between 0 and 1")
+			return
+		}
+
+		oc.logSampleRate = rate
+	}
+}
+
+func (oc *OtpClient) sampleLog() bool {
+	return oc.logSampleRate >= 1 || rand.Float64() < oc.logSampleRate
+}
+
+// RequestInfo describes a call made to the OTP service, for logging. The
+// secret and other sensitive values are redacted from it.
+type RequestInfo struct {
+	Operation string
+	Method    string
+	URL       string
+	// Headers are the headers set by the client, not including those added by
+	// the transport such as Content-Length.
+	Headers http.Header
+	// StatusCode is zero if no response was received.
+	StatusCode int
+	Duration   time.Duration
+	// Err is the error the call failed with, including error responses.
+	Err error
+}
+
+// RequestLogger receives an entry for every call made to the OTP service,
+// after it completes.
+type RequestLogger interface {
+	LogRequest(info RequestInfo)
+}
+
+// RequestLoggerFunc adapts a function to a RequestLogger.
+type RequestLoggerFunc func(info RequestInfo)
+
+func (f RequestLoggerFunc) LogRequest(info RequestInfo) {
+	f(info)
+}
+
+// WithLogger passes an entry for every call to logger, in addition to
+// OtpClient.Logger. Successful calls are sampled as per WithLogSampleRate.
+func WithLogger(logger RequestLogger) Option {
+	return func(oc *OtpClient) {
+		oc.requestLogger = logger
+	}
+}
+
+func (oc *OtpClient) logRequest(info RequestInfo) {
+	if oc.Logger == nil && oc.requestLogger == nil {
+		return
+	}
+
+	if oc.requestLogger != nil {
+		oc.requestLogger.LogRequest(info)
+	}
+
+	if oc.Logger == nil {
+		return
+	}
+
+	level := slog.LevelInfo
+	if info.Err != nil {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", info.Operation),
+		slog.String("method", info.Method),
+		slog.String("url", info.URL),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("duration", info.Duration),
+	}
+	if info.Err != nil {
+		attrs = append(attrs, slog.String("error", info.Err.Error()))
+	}
+
+	oc.Logger.LogAttrs(context.Background(), level, "otp service request", attrs...)
+}
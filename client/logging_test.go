@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type sampledSpan struct {
+	sampled *bool
+}
+
+func (s sampledSpan) SetLogSampled(sampled bool) {
+	*s.sampled = sampled
+}
+
+func (s sampledSpan) End(statusCode int, err error) {}
+
+type sampledTracer struct {
+	sampled *bool
+}
+
+func (t sampledTracer) Start(ctx context.Context, operation string) (context.Context, Span) {
+	return ctx, sampledSpan{t.sampled}
+}
+
+func (t sampledTracer) Inject(ctx context.Context, header http.Header) {}
+
+func TestLogSampleRateAlwaysLogsErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantLogged bool
+	}{
+		{"success", http.StatusOK, false},
+		{"not found", http.StatusNotFound, true},
+		{"server error", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, tt.status, `{"verified": true}`)
+
+			var logged []RequestInfo
+			var sampled bool
+			oc := New(srv.URL, "secret",
+				WithLogSampleRate(0),
+				WithLogger(RequestLoggerFunc(func(info RequestInfo) {
+					logged = append(logged, info)
+				})),
+				WithTracer(sampledTracer{&sampled}),
+			)
+
+			_, err := oc.GetUserOtp(context.Background(), 1)
+			if (err != nil) != tt.wantLogged {
+				t.Fatalf("GetUserOtp() error = %v", err)
+			}
+
+			if (len(logged) > 0) != tt.wantLogged {
+				t.Fatalf("logged %d requests, want logged %v", len(logged), tt.wantLogged)
+			}
+
+			if tt.wantLogged && logged[0].Err == nil {
+				t.Errorf("logged error = nil, want the call's error")
+			}
+
+			if sampled != tt.wantLogged {
+				t.Errorf("span sampled = %v, want %v", sampled, tt.wantLogged)
+			}
+		})
+	}
+}
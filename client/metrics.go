@@ -0,0 +1,26 @@
+package client
+
+import "time"
+
+// MetricsRecorder receives a measurement for every call made to the OTP
+// service, e.g. to feed a latency histogram and an error counter.
+type MetricsRecorder interface {
+	// ObserveRequest is called once a call completes, with the status code of
+	// its response, which is zero if none was received, and the error it
+	// failed with, including error responses.
+	ObserveRequest(op string, statusCode int, duration time.Duration, err error)
+}
+
+// MetricsRecorderFunc adapts a function to a MetricsRecorder.
+type MetricsRecorderFunc func(op string, statusCode int, duration time.Duration, err error)
+
+func (f MetricsRecorderFunc) ObserveRequest(op string, statusCode int, duration time.Duration, err error) {
+	f(op, statusCode, duration, err)
+}
+
+// WithMetricsRecorder reports every call made by the client to recorder.
+func WithMetricsRecorder(recorder MetricsRecorder) Option {
+	return func(oc *OtpClient) {
+		oc.metricsRecorder = recorder
+	}
+}
@@ -0,0 +1,289 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultRequestStartHeader = "X-Request-Start"
+	ClientMetadataHeader      = "X-Client-Meta"
+
+	// maxClientMetadataBytes bounds the encoded client metadata header, well
+	// below the header size limits of common servers and proxies.
+	maxClientMetadataBytes = 1024
+
+	// defaultMaxResponseBytes bounds response bodies, which are well below a
+	// kilobyte for every endpoint of the OTP service.
+	defaultMaxResponseBytes = 1 << 20
+)
+
+// Option configures an OtpClient when it is constructed with New.
+type Option func(*OtpClient)
+
+func (oc *OtpClient) setConfigErr(problem string) {
+	if oc.configErr == nil {
+		oc.configErr = &ConfigurationError{problem}
+	}
+}
+
+// WithHTTPClient sends the client's requests through httpClient, as if it was
+// assigned to OtpClient.HTTPClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(oc *OtpClient) {
+		if httpClient == nil {
+			oc.setConfigErr("http client is missing")
+			return
+		}
+
+		oc.HTTPClient = httpClient
+	}
+}
+
+// WithTimeout sets the client's Timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(oc *OtpClient) {
+		if timeout < 0 {
+			oc.setConfigErr("timeout must not be negative")
+			return
+		}
+
+		oc.Timeout = timeout
+	}
+}
+
+// WithUserAgent identifies the client's requests to the OTP service with
+// userAgent instead of the library's default User-Agent, which includes
+// its Version.
+func WithUserAgent(userAgent string) Option {
+	return func(oc *OtpClient) {
+		if userAgent == "" {
+			oc.setConfigErr("user agent must not be empty")
+			return
+		}
+
+		oc.userAgent = userAgent
+	}
+}
+
+// WithDisableKeepAlives closes connections to the OTP service after every
+// request, so short-lived processes such as CLI tools can exit without
+// waiting on idle connections. Long-running services should not enable this,
+// as every request would then pay for a new connection.
+func WithDisableKeepAlives() Option {
+	return func(oc *OtpClient) {
+		oc.transport.DisableKeepAlives = true
+	}
+}
+
+// WithRequestStartHeader stamps every request with the time it was sent, in
+// milliseconds since the unix epoch, in the X-Request-Start header. Retries
+// are stamped when they are sent, rather than with the time of the first
+// attempt. This lets the OTP service log how long requests spent queued.
+func WithRequestStartHeader() Option {
+	return WithRequestStartHeaderName(DefaultRequestStartHeader)
+}
+
+// WithRequestStartHeaderName is like WithRequestStartHeader, but sends the
+// timestamp in the given header instead.
+func WithRequestStartHeaderName(name string) Option {
+	return func(oc *OtpClient) {
+		oc.requestStartHeader = name
+	}
+}
+
+// requestStartDoer stamps every request it sends with the time it does so.
+type requestStartDoer struct {
+	doer   Doer
+	header string
+}
+
+func (d *requestStartDoer) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set(d.header, strconv.FormatInt(time.Now().UnixMilli(), 10))
+	return d.doer.Do(req)
+}
+
+// WithMaxContentLength rejects responses advertising a Content-Length above n
+// bytes with a ResponseTooLargeError, without reading their body.
+func WithMaxContentLength(n int64) Option {
+	return func(oc *OtpClient) {
+		oc.maxContentLength = n
+	}
+}
+
+// WithMaxResponseBytes fails calls whose response body exceeds n bytes with a
+// ResponseTooLargeError, no more than n bytes of it being read. Unlike
+// WithMaxContentLength, it also applies to bodies of unadvertised length and
+// to their decompressed size. It defaults to 1 MiB, which is far more than
+// any OTP service response needs; zero disables the limit.
+func WithMaxResponseBytes(n int64) Option {
+	return func(oc *OtpClient) {
+		if n < 0 {
+			oc.setConfigErr("max response bytes must not be negative")
+			return
+		}
+
+		oc.maxResponseBytes = n
+	}
+}
+
+// WithClientMetadata sends metadata, such as the application version and
+// platform, with every request as base64 encoded JSON in the X-Client-Meta
+// header. Metadata which encodes to more than 1 KiB is rejected.
+func WithClientMetadata(metadata map[string]string) Option {
+	return func(oc *OtpClient) {
+		metadataJson, err := json.Marshal(metadata)
+		if err != nil {
+			oc.setConfigErr(fmt.Sprintf("client metadata cannot be encoded: %s", err))
+			return
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(metadataJson)
+		if len(encoded) > maxClientMetadataBytes {
+			oc.setConfigErr(fmt.Sprintf(
+				"client metadata encodes to %d bytes, exceeding the limit of %d bytes",
+				len(encoded), maxClientMetadataBytes,
+			))
+			return
+		}
+
+		oc.clientMetadata = encoded
+	}
+}
+
+// WithTimeoutError replaces the error returned when a call exceeds its
+// context deadline with the one built by timeoutError, which receives the name
+// of the operation and how long the call was allowed to take. By default the
+// context error is returned, so errors.Is(err, context.DeadlineExceeded)
+// holds.
+func WithTimeoutError(timeoutError func(operation string, d time.Duration) error) Option {
+	return func(oc *OtpClient) {
+		oc.timeoutError = timeoutError
+	}
+}
+
+// WithRequireHTTPS rejects base urls which do not use https, so that the
+// secret can never be sent in plaintext because of a misconfiguration.
+func WithRequireHTTPS() Option {
+	return func(oc *OtpClient) {
+		oc.requireHTTPS = true
+	}
+}
+
+// WithAllowInsecure permits a plain http base url despite WithRequireHTTPS,
+// e.g. for a local OTP service during development and tests.
+func WithAllowInsecure() Option {
+	return func(oc *OtpClient) {
+		oc.allowInsecure = true
+	}
+}
+
+func (oc *OtpClient) checkScheme() {
+	if !oc.requireHTTPS || oc.allowInsecure {
+		return
+	}
+
+	baseUrl, err := url.Parse(oc.BaseUrl)
+	if err != nil {
+		oc.setConfigErr("base url is malformed")
+		return
+	}
+
+	if baseUrl.Scheme != "https" {
+		oc.setConfigErr(fmt.Sprintf("base url %q must use https", baseUrl.Redacted()))
+	}
+}
+
+// WithExpectContinue sends request bodies larger than threshold bytes with an
+// Expect: 100-continue header, so the server can reject a request before the
+// body is uploaded. Servers which ignore the header receive the body anyway
+// once the transport's ExpectContinueTimeout of one second elapses.
+func WithExpectContinue(threshold int) Option {
+	return func(oc *OtpClient) {
+		oc.expectContinueThreshold = threshold
+		if oc.transport.ExpectContinueTimeout == 0 {
+			oc.transport.ExpectContinueTimeout = time.Second
+		}
+	}
+}
+
+// WithReadBufferSize sets the size in bytes of the buffer used to read from
+// connections to the OTP service. Zero uses the transport's default of 4 KiB.
+func WithReadBufferSize(size int) Option {
+	return func(oc *OtpClient) {
+		oc.transport.ReadBufferSize = size
+	}
+}
+
+// WithWriteBufferSize sets the size in bytes of the buffer used to write to
+// connections to the OTP service. Zero uses the transport's default of 4 KiB.
+func WithWriteBufferSize(size int) Option {
+	return func(oc *OtpClient) {
+		oc.transport.WriteBufferSize = size
+	}
+}
+
+// WithMaxConnections bounds the client to n concurrent requests, and its
+// transport to n connections per host, all of which are kept idle for reuse.
+// Requests beyond the limit wait for one in flight to finish, or for their
+// context to be done. Together these keep the client from opening more than n
+// connections, e.g. to avoid exhausting file descriptors; the transport limit
+// alone would still let redirects to other hosts open further connections.
+func WithMaxConnections(n int) Option {
+	return func(oc *OtpClient) {
+		if n <= 0 {
+			oc.setConfigErr("max connections must be positive")
+			return
+		}
+
+		oc.transport.MaxConnsPerHost = n
+		// Otherwise connections beyond the transport's default of two idle
+		// ones are closed and dialed again, briefly exceeding the limit.
+		oc.transport.MaxIdleConnsPerHost = n
+		oc.connections = make(chan struct{}, n)
+	}
+}
+
+func (oc *OtpClient) acquireConnection(ctx context.Context) error {
+	if oc.connections == nil {
+		return nil
+	}
+
+	select {
+	case oc.connections <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (oc *OtpClient) releaseConnection() {
+	if oc.connections != nil {
+		<-oc.connections
+	}
+}
+
+// WithNotFoundProblem fills NotFoundError.Problem from the body of 404
+// responses, for endpoints which explain why a resource could not be found.
+// Responses without a problem body still result in a NotFoundError.
+func WithNotFoundProblem() Option {
+	return func(oc *OtpClient) {
+		oc.parseNotFoundBody = true
+	}
+}
+
+// WithStrictJSON fails calls whose response has fields unknown to the client,
+// with an error naming the field, to catch drift between the client and the
+// OTP service during development. By default such fields are ignored, so
+// servers can add fields without breaking older clients.
+func WithStrictJSON() Option {
+	return func(oc *OtpClient) {
+		oc.strictJSON = true
+	}
+}
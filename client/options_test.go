@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxConnections(t *testing.T) {
+	const limit = 3
+
+	var mu sync.Mutex
+	var open, maxOpen, inFlight, maxInFlight int
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": true}`))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch state {
+		case http.StateNew:
+			open++
+			maxOpen = max(maxOpen, open)
+		case http.StateClosed, http.StateHijacked:
+			open--
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	oc := New(srv.URL, "secret", WithMaxConnections(limit))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := oc.GetUserOtp(context.Background(), 1); err != nil {
+				t.Errorf("GetUserOtp() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if maxOpen > limit || maxInFlight > limit {
+		t.Errorf("max open connections = %d, max requests in flight = %d, want at most %d", maxOpen, maxInFlight, limit)
+	}
+}
+
+func TestRequestStartHeaderPerAttempt(t *testing.T) {
+	var stamps []int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stamp, err := strconv.ParseInt(r.Header.Get(DefaultRequestStartHeader), 10, 64)
+		if err != nil {
+			t.Errorf("%s = %q, want epoch millis", DefaultRequestStartHeader, r.Header.Get(DefaultRequestStartHeader))
+		}
+		stamps = append(stamps, stamp)
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(stamps) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"verified": true}`))
+	}))
+	defer srv.Close()
+
+	// The retry waits at least half the base delay, 10ms.
+	oc := New(srv.URL, "secret", WithRequestStartHeader(), WithRetryPolicy(2, 20*time.Millisecond))
+	if _, err := oc.GetUserOtp(context.Background(), 1); err != nil {
+		t.Fatalf("GetUserOtp() error = %v", err)
+	}
+
+	if len(stamps) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(stamps))
+	}
+
+	if stamps[1]-stamps[0] < 10 {
+		t.Errorf("retry stamped %dms after the first attempt, want at least 10ms", stamps[1]-stamps[0])
+	}
+}
+
+func BenchmarkBufferSizes(b *testing.B) {
+	// A body far larger than the default buffers, so that their size matters.
+	body := fmt.Sprintf(`{"verified": true, "auth_url": %q}`, strings.Repeat("a", 256<<10))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	// Zero is the transport's default of 4 KiB.
+	for _, size := range []int{0, 16 << 10, 64 << 10} {
+		b.Run(fmt.Sprintf("%dKiB", size>>10), func(b *testing.B) {
+			oc := New(srv.URL, "secret", WithReadBufferSize(size), WithWriteBufferSize(size))
+			b.SetBytes(int64(len(body)))
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := oc.GetUserOtp(context.Background(), 1); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
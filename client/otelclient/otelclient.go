@@ -0,0 +1,66 @@
+// Package otelclient traces the OTP service client with OpenTelemetry. It is
+// kept apart from the client package, so that programs which do not trace
+// with OpenTelemetry do not depend on it.
+//
+//	otpClient := client.New(baseUrl, secret, otelclient.WithTracerProvider(otel.GetTracerProvider()))
+package otelclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/osuAkatsuki/otp-service-client-go/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/osuAkatsuki/otp-service-client-go/client/otelclient"
+
+// WithTracerProvider starts a client span named after the operation for every
+// call made by the client, such as "VerifyOtp", recording the status code of
+// the response, any error and whether the call was logged as per
+// client.WithLogSampleRate. The span is propagated to the OTP service in the
+// W3C traceparent and tracestate headers.
+func WithTracerProvider(tp trace.TracerProvider) client.Option {
+	return client.WithTracer(&tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+	})
+}
+
+type tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func (t *tracer) Start(ctx context.Context, operation string) (context.Context, client.Span) {
+	ctx, span := t.tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &otelSpan{span}
+}
+
+func (t *tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetLogSampled(sampled bool) {
+	s.span.SetAttributes(attribute.Bool("otp_client.log_sampled", sampled))
+}
+
+func (s *otelSpan) End(statusCode int, err error) {
+	if statusCode != 0 {
+		s.span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+
+	s.span.End()
+}
@@ -0,0 +1,84 @@
+package otelclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/osuAkatsuki/otp-service-client-go/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantErr     bool
+		wantStatus  codes.Code
+		wantSampled bool
+	}{
+		{"success", http.StatusOK, `{"verified": true, "enabled": true}`, false, codes.Unset, false},
+		{"failure", http.StatusInternalServerError, `{"problem": "database unavailable"}`, true, codes.Error, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var traceparent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("traceparent")
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			// Successful calls are never logged, failed ones always are.
+			oc := client.New(srv.URL, "secret", WithTracerProvider(tp), client.WithLogSampleRate(0))
+			if _, err := oc.GetUserOtp(context.Background(), 1); (err != nil) != tt.wantErr {
+				t.Fatalf("GetUserOtp() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+
+			if span.Name() != "GetUserOtp" || span.SpanKind() != trace.SpanKindClient {
+				t.Errorf("span = %q of kind %v, want a client span named GetUserOtp", span.Name(), span.SpanKind())
+			}
+
+			attrs := attribute.NewSet(span.Attributes()...)
+			if got, _ := attrs.Value("http.response.status_code"); got.AsInt64() != int64(tt.status) {
+				t.Errorf("http.response.status_code = %v, want %d", got.Emit(), tt.status)
+			}
+
+			if got, ok := attrs.Value("otp_client.log_sampled"); !ok || got.AsBool() != tt.wantSampled {
+				t.Errorf("otp_client.log_sampled = %v, want %v", got.Emit(), tt.wantSampled)
+			}
+
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+
+			// Errors are recorded as exception events.
+			if recordedErr := len(span.Events()) > 0; recordedErr != tt.wantErr {
+				t.Errorf("span events = %v, want an error recorded %v", span.Events(), tt.wantErr)
+			}
+
+			if want := span.SpanContext().TraceID().String(); len(traceparent) < 35 || traceparent[3:35] != want {
+				t.Errorf("traceparent = %q, want the span's trace id %s", traceparent, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Otp is the set of calls to the OTP service made by OtpClient, so that code
+// using the client can depend on it and be tested against a mock.
+type Otp interface {
+	GetUserOtp(ctx context.Context, userId int, opts ...RequestOption) (GetUserOtpResponse, error)
+	GetUsersOtp(ctx context.Context, userIds []int, opts ...RequestOption) (map[int]GetUserOtpResponse, map[int]error)
+	GetUserOtpParameters(ctx context.Context, userId int, opts ...RequestOption) (OtpParameters, error)
+	GetUserOtpQRCodeDataURI(ctx context.Context, userId int, opts ...RequestOption) (string, error)
+	GetUserOtpStatus(ctx context.Context, userId int, opts ...RequestOption) (OtpStatus, error)
+	GetUsersOtpStatus(ctx context.Context, userIds []int, opts ...RequestOption) (map[int]OtpStatus, error)
+	CheckOtpStatusFromReader(ctx context.Context, r io.Reader, out io.Writer, opts ...RequestOption) error
+	IsUserOtpVerified(ctx context.Context, userId int, opts ...RequestOption) (bool, error)
+	IsOtpRequired(ctx context.Context, userId int, opts ...RequestOption) (bool, error)
+	NeedsOtpReEnrollment(ctx context.Context, userId int, opts ...RequestOption) (bool, string, error)
+
+	CreateUserOtp(ctx context.Context, userId int, opts ...RequestOption) (CreateUserOtpResponse, error)
+	EnrollUserOtp(ctx context.Context, userId int, token string, opts ...RequestOption) (EnrollResult, error)
+	MigrateUserOtpToTotp(ctx context.Context, userId int, opts ...RequestOption) (CreateUserOtpResponse, error)
+	EnableUserOtp(ctx context.Context, userId int, opts ...RequestOption) error
+	DisableUserOtp(ctx context.Context, userId int, opts ...RequestOption) error
+	DeleteUserOtp(ctx context.Context, userId int, opts ...RequestOption) error
+	DeleteUserOtpWithReason(ctx context.Context, userId int, reason string, opts ...RequestOption) error
+
+	VerifyOtp(ctx context.Context, userId int, token string, opts ...RequestOption) error
+	VerifyOtpWithResult(ctx context.Context, userId int, token string, opts ...RequestOption) (VerifyOtpResponse, error)
+	VerifySetupOtp(ctx context.Context, userId int, token string, opts ...RequestOption) error
+	VerifyOtpForSession(ctx context.Context, userId int, token string, opts ...RequestOption) (SessionToken, error)
+	VerifyOtpWithNonce(ctx context.Context, userId int, token, nonce string, opts ...RequestOption) (string, error)
+	VerifyOtpTrustDevice(ctx context.Context, userId int, token string, deviceLabel string, opts ...RequestOption) (TrustedDevice, error)
+	ValidateOtp(ctx context.Context, userId int, token string, opts ...RequestOption) error
+	ValidateOtpWithResult(ctx context.Context, userId int, token string, opts ...RequestOption) (ValidateResult, error)
+	ValidateOtpWithOutcome(ctx context.Context, userId int, token string, opts ...RequestOption) (ValidateOutcome, error)
+	PeekOtpValid(ctx context.Context, userId int, token string, opts ...RequestOption) (bool, error)
+	BatchValidateOtp(ctx context.Context, items []ValidateOtpRequest, opts ...RequestOption) ([]BatchValidateResult, error)
+	ValidateOtpOffline(secret, token string, t time.Time) (bool, error)
+
+	CreateUserHotp(ctx context.Context, userId int, opts ...RequestOption) (CreateUserHotpResponse, error)
+	ValidateHotp(ctx context.Context, userId int, token string, counter uint64, opts ...RequestOption) error
+
+	GenerateRecoveryCodes(ctx context.Context, userId int, opts ...RequestOption) ([]string, error)
+	VerifyRecoveryCode(ctx context.Context, userId int, code string, opts ...RequestOption) error
+	CanRegenerateRecoveryCodes(ctx context.Context, userId int, opts ...RequestOption) (bool, int, error)
+	RegenerateUserOtpRecoveryCodesStrict(ctx context.Context, userId int, opts ...RequestOption) ([]string, error)
+	IsRecoveryCodeValid(ctx context.Context, userId int, code string, opts ...RequestOption) (bool, error)
+	CountRemainingRecoveryCodes(ctx context.Context, userId int, opts ...RequestOption) (int, error)
+	GetRecoveryCodeCounts(ctx context.Context, userIds []int, opts ...RequestOption) (map[int]int, map[int]error)
+
+	GetRememberedDevice(ctx context.Context, id string, opts ...RequestOption) (GetRememberedDeviceResponse, error)
+	CreateRememberedDevice(ctx context.Context, userId int, opts ...RequestOption) (CreateRememberedDeviceResponse, error)
+
+	HealthCheck(ctx context.Context, opts ...RequestOption) error
+	GetServiceCapabilities(ctx context.Context, opts ...RequestOption) (Capabilities, error)
+	RefreshServiceCapabilities(ctx context.Context, opts ...RequestOption) (Capabilities, error)
+	ServerInfo(ctx context.Context, opts ...RequestOption) (ServerInfo, error)
+	DetectClockSkew(ctx context.Context, opts ...RequestOption) (time.Duration, error)
+}
+
+var _ Otp = (*OtpClient)(nil)
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	qrCodeSize = 256
+	// minQRCodeSize is the smallest size in pixels at which qr codes of
+	// provisioning urls remain reliably scannable.
+	minQRCodeSize = 64
+)
+
+var ErrEmptyAuthUrl = errors.New("auth url is empty")
+
+type AuthUrlTooLongError struct {
+	Length int
+}
+
+func (e *AuthUrlTooLongError) Error() string {
+	return fmt.Sprintf("auth url of %d bytes exceeds qr code capacity", e.Length)
+}
+
+func encodeQRCodePNG(authUrl string, size int) ([]byte, error) {
+	if authUrl == "" {
+		return nil, ErrEmptyAuthUrl
+	}
+
+	// qrcode.New only fails when the content does not fit in the largest
+	// qr code version.
+	code, err := qrcode.New(authUrl, qrcode.Medium)
+	if err != nil {
+		return nil, &AuthUrlTooLongError{len(authUrl)}
+	}
+
+	return code.PNG(size)
+}
+
+// GetUserOtpQRCodeDataURI returns the user's provisioning url as a
+// data:image/png;base64 qr code, ready to be embedded in an img tag.
+func (oc *OtpClient) GetUserOtpQRCodeDataURI(ctx context.Context, userId int, opts ...RequestOption) (string, error) {
+	otp, err := oc.GetUserOtp(ctx, userId, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	png, err := encodeQRCodePNG(otp.AuthUrl, qrCodeSize)
+	if err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
+
+// QRCodePNG encodes the provisioning url as a size by size pixel PNG qr code,
+// for the user to scan into their authenticator app. size must be at least 64.
+func (r CreateUserOtpResponse) QRCodePNG(size int) ([]byte, error) {
+	if size < minQRCodeSize {
+		return nil, fmt.Errorf("qr code size of %d pixels is below the minimum of %d pixels", size, minQRCodeSize)
+	}
+
+	return encodeQRCodePNG(r.AuthUrl, size)
+}
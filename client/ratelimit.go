@@ -0,0 +1,114 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitInfo describes the request budget advertised by the server
+// through the draft RateLimit-* response headers, or the older
+// X-RateLimit-* ones.
+type RateLimitInfo struct {
+	// Limit is zero if the server only advertised the remaining requests.
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func parseRateLimit(headers map[string][]string, now time.Time) (RateLimitInfo, bool) {
+	header := http.Header(headers)
+	if info, ok := parseDraftRateLimit(header, now); ok {
+		return info, true
+	}
+
+	return parseLegacyRateLimit(header, now)
+}
+
+func parseDraftRateLimit(header http.Header, now time.Time) (RateLimitInfo, bool) {
+	limit, err := strconv.Atoi(header.Get("RateLimit-Limit"))
+	if err != nil {
+		return RateLimitInfo{}, false
+	}
+
+	remaining, err := strconv.Atoi(header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return RateLimitInfo{}, false
+	}
+
+	info := RateLimitInfo{
+		Limit:     limit,
+		Remaining: remaining,
+	}
+
+	// RateLimit-Reset is the number of seconds until the budget resets.
+	if resetSeconds, err := strconv.Atoi(header.Get("RateLimit-Reset")); err == nil {
+		info.Reset = now.Add(time.Duration(resetSeconds) * time.Second)
+	}
+
+	return info, true
+}
+
+// minUnixReset separates X-RateLimit-Reset values given as unix timestamps
+// from those given as seconds until the reset, which are far smaller.
+const minUnixReset = 1_000_000_000
+
+func parseLegacyRateLimit(header http.Header, now time.Time) (RateLimitInfo, bool) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimitInfo{}, false
+	}
+
+	info := RateLimitInfo{Remaining: remaining}
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		info.Limit = limit
+	}
+
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if reset >= minUnixReset {
+			info.Reset = time.Unix(reset, 0)
+		} else {
+			info.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	return info, true
+}
+
+// parseRetryAfter parses a Retry-After header, given either as a number of
+// seconds or as an HTTP date, into how long to wait from now.
+func parseRetryAfter(headers map[string][]string, now time.Time) (time.Duration, bool) {
+	value := http.Header(headers).Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
+
+// LastRateLimit returns the rate limit advertised on the most recent response
+// which carried RateLimit-* or X-RateLimit-* headers. The boolean is false if no response has
+// advertised one yet.
+func (oc *OtpClient) LastRateLimit() (RateLimitInfo, bool) {
+	oc.mutex().Lock()
+	defer oc.mutex().Unlock()
+
+	if oc.lastRateLimit == nil {
+		return RateLimitInfo{}, false
+	}
+
+	return *oc.lastRateLimit, true
+}
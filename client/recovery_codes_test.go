@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRecoveryCodes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/1/otp/recovery-codes/verify", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/users/1/otp/recovery-codes/generate", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"codes": ["aaaa-bbbb", "cccc-dddd"]}`))
+	})
+	mux.HandleFunc("/users/2/otp/recovery-codes/generate", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"problem": "recovery codes already exist"}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	oc := New(srv.URL, "secret")
+	if err := oc.VerifyRecoveryCode(context.Background(), 1, "aaaa-bbbb"); err != nil {
+		t.Errorf("VerifyRecoveryCode() error = %v", err)
+	}
+
+	codes, err := oc.GenerateRecoveryCodes(context.Background(), 1)
+	if err != nil || !slices.Equal(codes, []string{"aaaa-bbbb", "cccc-dddd"}) {
+		t.Errorf("GenerateRecoveryCodes() = %v, %v, want the generated codes", codes, err)
+	}
+
+	if _, err := oc.GenerateRecoveryCodes(context.Background(), 2); !errors.Is(err, ErrConflict) {
+		t.Errorf("GenerateRecoveryCodes() error = %v, want a ConflictError", err)
+	}
+}
+
+func TestCountRemainingRecoveryCodes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/1/otp/recovery-codes/count", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"remaining": 3}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	oc := New(srv.URL, "secret")
+
+	var md ResponseMetadata
+	remaining, err := oc.CountRemainingRecoveryCodes(context.Background(), 1, WithResponseMetadata(&md))
+	if err != nil || remaining != 3 {
+		t.Errorf("CountRemainingRecoveryCodes() = %d, %v, want 3", remaining, err)
+	}
+
+	if md.StatusCode != http.StatusOK {
+		t.Errorf("metadata status = %d, want %d", md.StatusCode, http.StatusOK)
+	}
+
+	_, err = oc.CountRemainingRecoveryCodes(context.Background(), 2)
+	if !errors.Is(err, ErrOtpNotConfigured) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("CountRemainingRecoveryCodes() error = %v, want ErrOtpNotConfigured", err)
+	}
+}
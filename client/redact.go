@@ -0,0 +1,285 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+const redactedValue = "[REDACTED]"
+
+var sensitiveJsonFields = []string{"secret", "auth_url", "token", "session_token", "device_token", "codes"}
+
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return redactedValue
+}
+
+// redactJsonBody masks sensitive fields of a JSON body, including those of
+// nested objects and arrays, such as the OTPs of a bulk response. Any other
+// body is returned unchanged.
+func redactJsonBody(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return body
+	}
+
+	if !redactJsonValue(value) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+
+	return redactedBody
+}
+
+// redactJsonValue masks the sensitive fields of a decoded JSON value in
+// place, reporting whether there were any.
+func redactJsonValue(value any) bool {
+	redacted := false
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if slices.Contains(sensitiveJsonFields, key) {
+				value[key] = redactedValue
+				redacted = true
+			} else if redactJsonValue(field) {
+				redacted = true
+			}
+		}
+	case []any:
+		for _, element := range value {
+			if redactJsonValue(element) {
+				redacted = true
+			}
+		}
+	}
+
+	return redacted
+}
+
+// redactURL masks the password and the values of sensitive query parameters
+// of rawUrl.
+func redactURL(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+
+	_, hasPassword := u.User.Password()
+	redacted := hasPassword
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for _, field := range sensitiveJsonFields {
+			if query.Has(field) {
+				query.Set(field, redactedValue)
+				redacted = true
+			}
+		}
+
+		u.RawQuery = query.Encode()
+	}
+
+	if !redacted {
+		return rawUrl
+	}
+
+	return u.Redacted()
+}
+
+// redactRequestHeaders returns the headers set on request, with those
+// carrying credentials masked.
+func redactRequestHeaders(request http_client.HttpRequest) http.Header {
+	headers := make(http.Header)
+	for key, value := range request.Headers {
+		headers.Add(key, value)
+	}
+
+	for key, value := range request.SingularHeaders {
+		headers.Set(key, value)
+	}
+
+	for _, key := range request.SensitiveHeaders {
+		if headers.Get(key) != "" {
+			headers.Set(key, redactedValue)
+		}
+	}
+
+	return headers
+}
+
+// goString formats an already redacted value with %#v under typeName. The
+// value must be of a type without a GoString method, lest it call itself.
+func goString(typeName string, v any) string {
+	s := fmt.Sprintf("%#v", v)
+	return typeName + s[strings.Index(s, "{"):]
+}
+
+// Redacted returns a copy of the response with the secret and auth url masked.
+func (r GetUserOtpResponse) Redacted() GetUserOtpResponse {
+	r.Secret = redact(r.Secret)
+	r.AuthUrl = redact(r.AuthUrl)
+	return r
+}
+
+func (r GetUserOtpResponse) String() string {
+	redacted := r.Redacted()
+	return fmt.Sprintf(
+		"{Verified:%t Enabled:%t Secret:%s AuthUrl:%s}",
+		redacted.Verified, redacted.Enabled, redacted.Secret, redacted.AuthUrl,
+	)
+}
+
+func (r GetUserOtpResponse) LogValue() slog.Value {
+	redacted := r.Redacted()
+	return slog.GroupValue(
+		slog.Bool("verified", redacted.Verified),
+		slog.Bool("enabled", redacted.Enabled),
+		slog.String("secret", redacted.Secret),
+		slog.String("auth_url", redacted.AuthUrl),
+	)
+}
+
+func (r GetUserOtpResponse) GoString() string {
+	type plain GetUserOtpResponse
+	return goString("client.GetUserOtpResponse", plain(r.Redacted()))
+}
+
+// Redacted returns a copy of the response with the secret and auth url masked.
+func (r CreateUserOtpResponse) Redacted() CreateUserOtpResponse {
+	r.Secret = redact(r.Secret)
+	r.AuthUrl = redact(r.AuthUrl)
+	return r
+}
+
+func (r CreateUserOtpResponse) String() string {
+	redacted := r.Redacted()
+	return fmt.Sprintf(
+		"{Secret:%s AuthUrl:%s OtpId:%s}",
+		redacted.Secret, redacted.AuthUrl, redacted.OtpId,
+	)
+}
+
+func (r CreateUserOtpResponse) LogValue() slog.Value {
+	redacted := r.Redacted()
+	return slog.GroupValue(
+		slog.String("secret", redacted.Secret),
+		slog.String("auth_url", redacted.AuthUrl),
+		slog.String("otp_id", redacted.OtpId),
+	)
+}
+
+func (r CreateUserOtpResponse) GoString() string {
+	type plain CreateUserOtpResponse
+	return goString("client.CreateUserOtpResponse", plain(r.Redacted()))
+}
+
+// Redacted returns a copy of the response with the secret and auth url masked.
+func (r CreateUserHotpResponse) Redacted() CreateUserHotpResponse {
+	r.Secret = redact(r.Secret)
+	r.AuthUrl = redact(r.AuthUrl)
+	return r
+}
+
+func (r CreateUserHotpResponse) String() string {
+	redacted := r.Redacted()
+	return fmt.Sprintf(
+		"{Secret:%s AuthUrl:%s Counter:%d}",
+		redacted.Secret, redacted.AuthUrl, redacted.Counter,
+	)
+}
+
+func (r CreateUserHotpResponse) LogValue() slog.Value {
+	redacted := r.Redacted()
+	return slog.GroupValue(
+		slog.String("secret", redacted.Secret),
+		slog.String("auth_url", redacted.AuthUrl),
+		slog.Uint64("counter", redacted.Counter),
+	)
+}
+
+func (r CreateUserHotpResponse) GoString() string {
+	type plain CreateUserHotpResponse
+	return goString("client.CreateUserHotpResponse", plain(r.Redacted()))
+}
+
+func (t SessionToken) String() string {
+	return fmt.Sprintf("{Token:%s ExpiresAt:%s}", redact(t.Token), t.ExpiresAt)
+}
+
+func (t SessionToken) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("token", redact(t.Token)),
+		slog.Time("expires_at", t.ExpiresAt),
+	)
+}
+
+func (t SessionToken) GoString() string {
+	type plain SessionToken
+	t.Token = redact(t.Token)
+	return goString("client.SessionToken", plain(t))
+}
+
+func (d TrustedDevice) String() string {
+	return fmt.Sprintf("{Token:%s ExpiresAt:%s}", redact(d.Token), d.ExpiresAt)
+}
+
+func (d TrustedDevice) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("token", redact(d.Token)),
+		slog.Time("expires_at", d.ExpiresAt),
+	)
+}
+
+func (d TrustedDevice) GoString() string {
+	type plain TrustedDevice
+	d.Token = redact(d.Token)
+	return goString("client.TrustedDevice", plain(d))
+}
+
+func (p OtpParameters) String() string {
+	return fmt.Sprintf(
+		"{Type:%s Issuer:%s Account:%s Secret:%s Algorithm:%s Digits:%d Period:%s}",
+		p.Type, p.Issuer, p.Account, redact(p.Secret), p.Algorithm, p.Digits, p.Period,
+	)
+}
+
+func (p OtpParameters) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("type", p.Type),
+		slog.String("issuer", p.Issuer),
+		slog.String("account", p.Account),
+		slog.String("secret", redact(p.Secret)),
+		slog.String("algorithm", p.Algorithm),
+		slog.Int("digits", p.Digits),
+		slog.Duration("period", p.Period),
+	)
+}
+
+func (p OtpParameters) GoString() string {
+	type plain OtpParameters
+	p.Secret = redact(p.Secret)
+	return goString("client.OtpParameters", plain(p))
+}
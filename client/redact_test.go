@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactJsonBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"top level", `{"secret":"JBSWY3DPEHPK3PXP","verified":true}`, `{"secret":"[REDACTED]","verified":true}`},
+		{"nested object", `{"otps":{"1":{"auth_url":"otpauth://totp/a?secret=JBSWY3DPEHPK3PXP","enabled":true,"secret":"JBSWY3DPEHPK3PXP"}}}`, `{"otps":{"1":{"auth_url":"[REDACTED]","enabled":true,"secret":"[REDACTED]"}}}`},
+		{"array of objects", `[{"token":"123456","user_id":1}]`, `[{"token":"[REDACTED]","user_id":1}]`},
+		{"recovery codes", `{"codes":["aaaa-bbbb","cccc-dddd"],"old_codes_invalidated":true}`, `{"codes":"[REDACTED]","old_codes_invalidated":true}`},
+		{"large number", `{"expires_at":9007199254740993,"session_token":"abc"}`, `{"expires_at":9007199254740993,"session_token":"[REDACTED]"}`},
+		{"nothing sensitive", `{"verified": true}`, `{"verified": true}`},
+		{"not json", `secret`, `secret`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactJsonBody([]byte(tt.body))); got != tt.want {
+				t.Errorf("redactJsonBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactedFormatting(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	authUrl := "otpauth://totp/osu?secret=" + secret
+
+	values := map[string]any{
+		"GetUserOtpResponse":     GetUserOtpResponse{Verified: true, Secret: secret, AuthUrl: authUrl},
+		"CreateUserOtpResponse":  CreateUserOtpResponse{Secret: secret, AuthUrl: authUrl, OtpId: "device-1"},
+		"CreateUserHotpResponse": CreateUserHotpResponse{Secret: secret, AuthUrl: authUrl, Counter: 3},
+		"SessionToken":           SessionToken{Token: secret, ExpiresAt: time.Unix(1700000000, 0)},
+		"TrustedDevice":          TrustedDevice{Token: secret, ExpiresAt: time.Unix(1700000000, 0)},
+		"OtpParameters":          OtpParameters{Type: "totp", Secret: secret, Digits: 6},
+	}
+
+	for name, v := range values {
+		for _, verb := range []string{"%v", "%+v", "%s", "%#v"} {
+			t.Run(name+" "+verb, func(t *testing.T) {
+				got := fmt.Sprintf(verb, v)
+				if strings.Contains(got, secret) || !strings.Contains(got, redactedValue) {
+					t.Errorf("Sprintf(%q) = %s, want the secret redacted", verb, got)
+				}
+
+				if verb == "%#v" && !strings.HasPrefix(got, "client."+name+"{") {
+					t.Errorf("Sprintf(%q) = %s, want Go syntax for client.%s", verb, got, name)
+				}
+			})
+		}
+	}
+}
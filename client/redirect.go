@@ -0,0 +1,42 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// defaultMaxRedirects matches the limit of http.Client's default policy.
+const defaultMaxRedirects = 10
+
+// WithMaxRedirects follows at most n redirects, after which the last redirect
+// response is returned as is. Zero disables following redirects, surfacing the
+// 3xx response to the caller.
+func WithMaxRedirects(n int) Option {
+	return func(oc *OtpClient) {
+		if n < 0 {
+			oc.setConfigErr("max redirects must not be negative")
+			return
+		}
+
+		oc.maxRedirects = n
+	}
+}
+
+// checkRedirect limits the number of redirects followed, and keeps the secret
+// from being sent to any host other than the one originally requested, or in
+// plaintext after a redirect from https to http.
+func (oc *OtpClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > oc.maxRedirects {
+		return http.ErrUseLastResponse
+	}
+
+	downgraded := via[0].URL.Scheme == "https" && req.URL.Scheme != "https"
+	if req.URL.Host != via[0].URL.Host || downgraded {
+		for _, header := range http_client.SensitiveHeadersFromContext(via[0].Context()) {
+			req.Header.Del(header)
+		}
+	}
+
+	return nil
+}
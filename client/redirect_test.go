@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRedirectStripsSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		from, to   string
+		wantSecret bool
+	}{
+		{"same host", "https://otp.example.com", "https://otp.example.com/v2", true},
+		{"other host", "https://otp.example.com", "https://evil.example.com", false},
+		{"https to http", "https://otp.example.com", "http://otp.example.com", false},
+		{"http to http", "http://otp.example.com", "http://otp.example.com/v2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var redirectedSecret string
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				rec := httptest.NewRecorder()
+				if req.URL.Path == "/users/1/otp" {
+					rec.Header().Set("Location", tt.to+"/redirected")
+					rec.WriteHeader(http.StatusFound)
+					return rec.Result(), nil
+				}
+
+				redirectedSecret = req.Header.Get(DefaultAuthHeader)
+				rec.Header().Set("Content-Type", "application/json")
+				rec.WriteString(`{"verified": true}`)
+				return rec.Result(), nil
+			})
+
+			oc := New(tt.from, "secret", WithHTTPClient(&http.Client{Transport: transport}))
+			if _, err := oc.GetUserOtp(context.Background(), 1); err != nil {
+				t.Fatalf("GetUserOtp() error = %v", err)
+			}
+
+			if (redirectedSecret != "") != tt.wantSecret {
+				t.Errorf("secret sent after redirect = %q, want sent %v", redirectedSecret, tt.wantSecret)
+			}
+		})
+	}
+}
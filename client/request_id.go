@@ -0,0 +1,45 @@
+package client
+
+import "context"
+
+// RequestIDHeader carries the id correlating a call with the request that
+// caused it, see WithRequestIDContextKey.
+const RequestIDHeader = "X-Request-ID"
+
+// WithRequestIDContextKey sends the string stored under key in the context of
+// every call as its X-Request-ID header, so request ids stamped on inbound
+// requests flow on to the OTP service. Calls whose context has no id send no
+// header, unless WithGeneratedRequestIDs is used too.
+func WithRequestIDContextKey(key any) Option {
+	return func(oc *OtpClient) {
+		if key == nil {
+			oc.setConfigErr("request id context key is missing")
+			return
+		}
+
+		oc.requestIDKey = key
+	}
+}
+
+// WithGeneratedRequestIDs sends a random X-Request-ID with calls which have no
+// id of their own, see WithRequestIDContextKey. Retries of a call share its id.
+func WithGeneratedRequestIDs() Option {
+	return func(oc *OtpClient) {
+		oc.generateRequestIDs = true
+	}
+}
+
+// requestID returns the id to send with a call made with ctx, or "" if none.
+func (oc *OtpClient) requestID(ctx context.Context) string {
+	if oc.requestIDKey != nil {
+		if id, ok := ctx.Value(oc.requestIDKey).(string); ok && id != "" {
+			return id
+		}
+	}
+
+	if oc.generateRequestIDs {
+		return newUUID()
+	}
+
+	return ""
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// ResponseMetadata describes the HTTP response behind a call.
+type ResponseMetadata struct {
+	StatusCode int
+	Headers    http.Header
+	// RawBody is the body as read by the client, only set when
+	// WithResponseBodyCopy is used.
+	RawBody []byte
+	// RateLimit is the rate limit advertised by the response, or nil if it
+	// advertised none. Unlike LastRateLimit it is not shared between calls.
+	RateLimit *RateLimitInfo
+}
+
+type requestOptions struct {
+	headers  map[string]string
+	metadata *ResponseMetadata
+	copyBody bool
+
+	idempotencyKey string
+}
+
+// RequestOption customizes a single call made through the client.
+type RequestOption func(*requestOptions)
+
+// WithHeader adds a header to the outgoing request, alongside any other
+// values of the same header. It cannot be used to override the authentication
+// header.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string)
+		}
+
+		o.headers[key] = value
+	}
+}
+
+// WithResponseMetadata fills md with the status code and headers of the
+// response once the call completes. Batch methods which fan out to concurrent
+// per-user requests, such as GetRecoveryCodeCounts, leave it untouched when
+// querying several users.
+func WithResponseMetadata(md *ResponseMetadata) RequestOption {
+	return func(o *requestOptions) {
+		o.metadata = md
+	}
+}
+
+func withoutResponseMetadata() RequestOption {
+	return func(o *requestOptions) {
+		o.metadata = nil
+		o.copyBody = false
+	}
+}
+
+// WithResponseBodyCopy additionally retains the response body in the
+// metadata passed to WithResponseMetadata, e.g. for an audit trail. Secrets
+// in JSON bodies are redacted from the copy.
+func WithResponseBodyCopy() RequestOption {
+	return func(o *requestOptions) {
+		o.copyBody = true
+	}
+}
+
+// WithIdempotencyKey identifies a logical create operation, so that repeating
+// it with the same key returns the original result from the client's
+// idempotency cache, see WithIdempotencyCache. The key is also sent in the
+// Idempotency-Key header of CreateUserOtp, in place of the random key it
+// otherwise sends.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+func applyRequestOptions(opts []RequestOption) requestOptions {
+	var options requestOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+func (o requestOptions) recordMetadata(resp http_client.HttpResponse) {
+	if o.metadata == nil {
+		return
+	}
+
+	o.metadata.StatusCode = resp.StatusCode
+	o.metadata.Headers = http.Header(resp.Headers)
+	o.metadata.RateLimit = nil
+	if rateLimit, ok := parseRateLimit(resp.Headers, time.Now()); ok {
+		o.metadata.RateLimit = &rateLimit
+	}
+
+	if o.copyBody {
+		o.metadata.RawBody = redactJsonBody(resp.RawBody)
+	}
+}
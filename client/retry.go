@@ -0,0 +1,323 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+// defaultMaxRetryDelay bounds the wait between attempts unless
+// WithMaxRetryDelay is used.
+const defaultMaxRetryDelay = time.Minute
+
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// WithRetryPolicy retries idempotent requests which failed to reach the
+// server, received a server error or were rate limited, waiting around
+// baseDelay after the first attempt and doubling the wait after every further
+// attempt. Each wait is randomized by up to half its length, so that clients
+// retrying at once do not hit the server in lockstep, and a Retry-After header
+// on the response is honored instead of the computed wait, up to the limit
+// set with WithMaxRetryDelay. maxAttempts includes the first attempt. Requests
+// which are not idempotent, such as verifying an OTP, are never retried.
+func WithRetryPolicy(maxAttempts int, baseDelay time.Duration) Option {
+	return func(oc *OtpClient) {
+		oc.retryPolicy.maxAttempts = maxAttempts
+		oc.retryPolicy.baseDelay = baseDelay
+	}
+}
+
+// WithMaxRetryDelay bounds every wait between attempts to d, including those
+// asked for by a Retry-After header, so that a misbehaving server or proxy
+// cannot stall calls without a deadline. It defaults to one minute.
+func WithMaxRetryDelay(d time.Duration) Option {
+	return func(oc *OtpClient) {
+		if d <= 0 {
+			oc.setConfigErr("max retry delay must be positive")
+			return
+		}
+
+		oc.retryPolicy.maxDelay = d
+	}
+}
+
+// WithRetryNotify calls notify before every retry, with the number of the
+// attempt which failed, the error it failed with and how long the client will
+// wait before the next attempt.
+func WithRetryNotify(notify func(attempt int, err error, nextDelay time.Duration)) Option {
+	return func(oc *OtpClient) {
+		oc.retryNotify = notify
+	}
+}
+
+// maxWait returns the longest the client waits between attempts.
+func (p retryPolicy) maxWait() time.Duration {
+	if p.maxDelay <= 0 {
+		return defaultMaxRetryDelay
+	}
+
+	return p.maxDelay
+}
+
+func (p retryPolicy) delay(attempt int) time.Duration {
+	delay := p.baseDelay << (attempt - 1)
+	if delay <= 1 {
+		return delay
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)))
+}
+
+// execute runs a request for the named operation, retrying it according to
+// the client's retry policy if it is idempotent. POST requests are only
+// considered idempotent if they carry an idempotency key.
+func execute(ctx context.Context, oc *OtpClient, op string, method string, request http_client.HttpRequest, opts []RequestOption, do func(ctx context.Context) (http_client.HttpResponse, error)) error {
+	options := applyRequestOptions(opts)
+	idempotent := method != http.MethodPost || hasIdempotencyKey(request)
+
+	callerCtx, callStart := ctx, time.Now()
+	if oc.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, oc.Timeout)
+		defer cancel()
+	}
+
+	var span Span
+	if oc.tracer != nil {
+		ctx, span = oc.tracer.Start(ctx, op)
+	}
+
+	start := time.Now()
+	resp, err := executeAttempts(ctx, oc, idempotent, options, do)
+	duration := time.Since(start)
+
+	// Error responses count as failures of the call too.
+	callErr := err
+	if callErr == nil {
+		callErr = handleResponse(resp)
+	}
+	logged := callErr != nil || oc.sampleLog()
+
+	if span != nil {
+		if sampledSpan, ok := span.(LogSampledSpan); ok {
+			sampledSpan.SetLogSampled(logged)
+		}
+
+		span.End(resp.StatusCode, callErr)
+	}
+
+	if oc.metricsRecorder != nil {
+		oc.metricsRecorder.ObserveRequest(op, resp.StatusCode, duration, callErr)
+	}
+
+	if logged {
+		oc.logRequest(RequestInfo{
+			Operation:  op,
+			Method:     method,
+			URL:        redactURL(request.Url),
+			Headers:    redactRequestHeaders(request),
+			StatusCode: resp.StatusCode,
+			Duration:   duration,
+			Err:        callErr,
+		})
+	}
+	oc.reportDeprecation(op, resp)
+
+	if err != nil && oc.timeoutError != nil && errors.Is(err, context.DeadlineExceeded) {
+		return oc.timeoutError(op, oc.allowedDuration(callerCtx, callStart))
+	}
+
+	return err
+}
+
+// allowedDuration returns how long a call started at start was allowed to
+// take before timing out, which is bounded by the caller's ctx, the client's
+// Timeout and the timeout of its http.Client.
+func (oc *OtpClient) allowedDuration(ctx context.Context, start time.Time) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if d := deadline.Sub(start); oc.Timeout <= 0 || d < oc.Timeout {
+			return d
+		}
+	}
+
+	if oc.Timeout > 0 {
+		return oc.Timeout
+	}
+
+	if oc.HTTPClient != nil && oc.HTTPClient.Timeout > 0 {
+		return oc.HTTPClient.Timeout
+	}
+
+	return time.Since(start)
+}
+
+func executeAttempts(ctx context.Context, oc *OtpClient, idempotent bool, options requestOptions, do func(ctx context.Context) (http_client.HttpResponse, error)) (http_client.HttpResponse, error) {
+	attempts := oc.retryPolicy.maxAttempts
+	if attempts < 1 || !idempotent {
+		attempts = 1
+	}
+
+	var resp http_client.HttpResponse
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err := oc.acquireConnection(ctx); err != nil {
+			return resp, err
+		}
+		resp, err = do(ctx)
+		oc.releaseConnection()
+
+		observeResponse(oc, resp)
+
+		var retryable bool
+		cause := err
+		if resp.StatusCode == 0 {
+			err = classifyTransportError(err)
+			cause = err
+			retryable = isRetryableTransportError(err)
+		} else {
+			options.recordMetadata(resp)
+			if cause == nil {
+				cause = handleResponse(resp)
+			}
+			retryable = isRetryableStatus(resp.StatusCode)
+		}
+
+		if !retryable || attempt == attempts {
+			break
+		}
+
+		delay := oc.retryPolicy.delay(attempt)
+		if retryAfter, ok := parseRetryAfter(resp.Headers, time.Now()); ok {
+			delay = retryAfter
+		}
+		delay = min(delay, oc.retryPolicy.maxWait())
+
+		// Give up rather than wait in vain if the wait would exceed the
+		// deadline, so that the last response or error is returned.
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+
+		if oc.retryNotify != nil {
+			oc.retryNotify(attempt, cause, delay)
+		}
+
+		// The call ends with the context's error rather than the response
+		// it was waiting to retry.
+		if err := sleepContext(ctx, delay); err != nil {
+			return http_client.HttpResponse{}, err
+		}
+	}
+
+	return resp, err
+}
+
+// sleepContext waits for d to pass, returning early with the context's error
+// if ctx is done first. If ctx's deadline would pass before d does, it returns
+// context.DeadlineExceeded immediately rather than waiting in vain. It is used
+// for the backoff between attempts; waiting for a connection in
+// acquireConnection is bounded by ctx directly instead.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func classifyTransportError(err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return &DNSError{dnsErr}
+	}
+
+	return err
+}
+
+// isRetryableStatus reports whether a response with the given status code is
+// a server error or rate limit which may succeed when retried. 501 Not Implemented is
+// excluded, as older servers answer unsupported endpoints with it.
+func isRetryableStatus(statusCode int) bool {
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	return statusCode >= 500 && statusCode != http.StatusNotImplemented
+}
+
+func isRetryableTransportError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var dnsErr *DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.Temporary()
+	}
+
+	return isConnectionError(err)
+}
+
+// isConnectionError reports whether err is a failure to connect to the server
+// or a connection lost while talking to it, which may not recur. Errors from a
+// misconfigured TLS setup, such as an untrusted certificate, are not, as they
+// fail again on every attempt.
+func isConnectionError(err error) bool {
+	if isTLSError(err) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	// The server closed the connection before responding, e.g. as it was
+	// idle for too long. Only errors of the transport count, not those of
+	// reading or decoding a body.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && (errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF)) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isTLSError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &recordErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
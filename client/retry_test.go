@@ -0,0 +1,305 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// stubDialer fails every connection attempt with err, standing in for the
+// resolver or the network.
+func stubDialer(err error) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+}
+
+func countAttempts(t *testing.T, baseUrl string, httpClient *http.Client) (int, error) {
+	t.Helper()
+
+	attempts := 1
+	oc := New(baseUrl, "secret",
+		WithHTTPClient(httpClient),
+		WithRetryPolicy(3, time.Millisecond),
+		WithRetryNotify(func(attempt int, err error, nextDelay time.Duration) {
+			attempts++
+		}),
+	)
+
+	_, err := oc.GetUserOtp(context.Background(), 1)
+	return attempts, err
+}
+
+func TestTransportErrorRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		dialErr      error
+		wantAttempts int
+	}{
+		{"nxdomain", &net.DNSError{Err: "no such host", Name: "otp.invalid", IsNotFound: true}, 1},
+		{"servfail", &net.DNSError{Err: "server misbehaving", Name: "otp.invalid", IsTemporary: true}, 3},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", Name: "otp.invalid", IsTimeout: true}, 3},
+		{"connection refused", syscall.ECONNREFUSED, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := &http.Client{Transport: &http.Transport{DialContext: stubDialer(tt.dialErr)}}
+
+			attempts, err := countAttempts(t, "http://otp.invalid", httpClient)
+			if err == nil {
+				t.Fatal("GetUserOtp() succeeded, want an error")
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d (err: %v)", attempts, tt.wantAttempts, err)
+			}
+
+			var dnsErr *DNSError
+			if _, isDNS := tt.dialErr.(*net.DNSError); isDNS != errors.As(err, &dnsErr) {
+				t.Errorf("GetUserOtp() error = %v, DNSError wanted: %v", err, isDNS)
+			}
+		})
+	}
+}
+
+func TestUntrustedCertificateIsNotRetried(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	// The default transport does not trust the test server's certificate.
+	attempts, err := countAttempts(t, srv.URL, &http.Client{Transport: &http.Transport{}})
+	if err == nil {
+		t.Fatal("GetUserOtp() succeeded, want a certificate error")
+	}
+
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (err: %v)", attempts, err)
+	}
+}
+
+func TestTimeoutErrorDuration(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want time.Duration
+	}{
+		{"client timeout", []Option{WithTimeout(20 * time.Millisecond)}, 20 * time.Millisecond},
+		{"http client timeout", []Option{WithHTTPClient(&http.Client{Timeout: 30 * time.Millisecond})}, 30 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got time.Duration
+			errTimeout := errors.New("timed out")
+			opts := append(tt.opts, WithTimeoutError(func(operation string, d time.Duration) error {
+				got = d
+				return errTimeout
+			}))
+
+			_, err := New(srv.URL, "secret", opts...).GetUserOtp(context.Background(), 1)
+			if !errors.Is(err, errTimeout) {
+				t.Fatalf("GetUserOtp() error = %v, want the custom timeout error", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("timeout error duration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffExceedingDeadlineReturnsResponse(t *testing.T) {
+	srv := serve(t, http.StatusServiceUnavailable, `{"problem": "down for maintenance"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The first backoff of at least half a second exceeds the deadline.
+	start := time.Now()
+	_, err := New(srv.URL, "secret", WithRetryPolicy(3, time.Second)).GetUserOtp(ctx, 1)
+
+	var unknownErr *UnknownError
+	if !errors.As(err, &unknownErr) || unknownErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GetUserOtp() error = %v, want the 503 response", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("GetUserOtp() took %v, want it to give up without waiting", elapsed)
+	}
+}
+
+func TestWaitsShareDeadline(t *testing.T) {
+	const deadline = 150 * time.Millisecond
+
+	release := make(chan struct{})
+	var attempts int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/1/otp", func(w http.ResponseWriter, r *http.Request) {
+		// Holds the only connection while user 2 is fetched.
+		<-release
+	})
+	mux.HandleFunc("/users/2/otp", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts%2 == 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	oc := New(srv.URL, "secret", WithMaxConnections(1), WithRetryPolicy(100, 10*time.Millisecond))
+
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		oc.GetUserOtp(context.Background(), 1)
+	}()
+	time.AfterFunc(deadline/3, func() { close(release) })
+
+	// Wait for the blocking call to take the connection.
+	for len(oc.connections) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	start := time.Now()
+	_, err := oc.GetUserOtp(ctx, 2)
+	elapsed := time.Since(start)
+	<-blocked
+
+	if err == nil {
+		t.Fatal("GetUserOtp() succeeded, want an error")
+	}
+
+	if attempts < 2 {
+		t.Errorf("attempts = %d, want the call to be retried after waiting for the connection", attempts)
+	}
+
+	if elapsed > deadline+20*time.Millisecond {
+		t.Errorf("GetUserOtp() took %v, want at most the deadline of %v", elapsed, deadline)
+	}
+}
+
+func TestCancelAbortsBackoff(t *testing.T) {
+	srv := serve(t, http.StatusServiceUnavailable, ``)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oc := New(srv.URL, "secret",
+		WithRetryPolicy(3, time.Minute),
+		WithRetryNotify(func(attempt int, err error, nextDelay time.Duration) {
+			time.AfterFunc(10*time.Millisecond, cancel)
+		}),
+	)
+
+	start := time.Now()
+	_, err := oc.GetUserOtp(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetUserOtp() error = %v, want context.Canceled", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("GetUserOtp() took %v, want the backoff to be aborted", elapsed)
+	}
+}
+
+func TestCancelDuringBackoffReturnsContextError(t *testing.T) {
+	srv := serve(t, http.StatusServiceUnavailable, ``)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var observedStatus int
+	var observedErr error
+	oc := New(srv.URL, "secret",
+		WithRetryPolicy(2, time.Minute),
+		WithRetryNotify(func(attempt int, err error, nextDelay time.Duration) {
+			cancel()
+		}),
+		WithMetricsRecorder(MetricsRecorderFunc(func(op string, statusCode int, duration time.Duration, err error) {
+			observedStatus, observedErr = statusCode, err
+		})),
+	)
+
+	_, err := oc.GetUserOtp(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetUserOtp() error = %v, want context.Canceled", err)
+	}
+
+	// The 503 being waited on is not what the call ended with.
+	var unknownErr *UnknownError
+	if errors.As(err, &unknownErr) {
+		t.Errorf("GetUserOtp() error = %v, want no error for the 503", err)
+	}
+
+	if observedStatus != 0 || !errors.Is(observedErr, context.Canceled) {
+		t.Errorf("observed status %d, error %v, want no status and context.Canceled", observedStatus, observedErr)
+	}
+}
+
+func TestRetryAfterIsClamped(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantDelay time.Duration
+	}{
+		{"default", nil, defaultMaxRetryDelay},
+		{"max retry delay", []Option{WithMaxRetryDelay(20 * time.Millisecond)}, 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "86400")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var delay time.Duration
+			opts := append([]Option{
+				WithRetryPolicy(2, time.Millisecond),
+				WithRetryNotify(func(attempt int, err error, nextDelay time.Duration) {
+					delay = nextDelay
+					// Waiting out the default would slow the test down.
+					if nextDelay > time.Second {
+						cancel()
+					}
+				}),
+			}, tt.opts...)
+
+			New(srv.URL, "secret", opts...).GetUserOtp(ctx, 1)
+			if delay != tt.wantDelay {
+				t.Errorf("waited %v, want the day asked for clamped to %v", delay, tt.wantDelay)
+			}
+		})
+	}
+}
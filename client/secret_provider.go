@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SecretProvider supplies the secret used to authenticate with the OTP
+// service, e.g. by reading it from a secret store.
+type SecretProvider interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider.
+type SecretProviderFunc func(ctx context.Context) (string, error)
+
+func (f SecretProviderFunc) Secret(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// WithSecretProvider fetches the secret from provider before every request.
+// Once a provider is configured, the static secret and SetSecret are ignored.
+func WithSecretProvider(provider SecretProvider) Option {
+	return func(oc *OtpClient) {
+		oc.secretProvider = provider
+	}
+}
+
+type cachingSecretProvider struct {
+	underlying SecretProvider
+	ttl        time.Duration
+
+	mu        sync.Mutex
+	secret    string
+	expiresAt time.Time
+	refresh   *secretRefresh
+}
+
+type secretRefresh struct {
+	done   chan struct{}
+	secret string
+	err    error
+}
+
+// CachingSecretProvider wraps underlying so that a fetched secret is reused for
+// ttl. Concurrent requests during a refresh share a single fetch from
+// underlying instead of each calling it.
+func CachingSecretProvider(underlying SecretProvider, ttl time.Duration) SecretProvider {
+	return &cachingSecretProvider{
+		underlying: underlying,
+		ttl:        ttl,
+	}
+}
+
+func (p *cachingSecretProvider) Secret(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	if p.secret != "" && time.Now().Before(p.expiresAt) {
+		secret := p.secret
+		p.mu.Unlock()
+		return secret, nil
+	}
+
+	refresh := p.refresh
+	if refresh == nil {
+		refresh = &secretRefresh{done: make(chan struct{})}
+		p.refresh = refresh
+
+		// The refresh is shared with other callers, so it must not be
+		// cancelled along with the caller which happened to start it.
+		go p.doRefresh(context.WithoutCancel(ctx), refresh)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.secret, refresh.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (p *cachingSecretProvider) doRefresh(ctx context.Context, refresh *secretRefresh) {
+	secret, err := p.underlying.Secret(ctx)
+
+	p.mu.Lock()
+	if err == nil {
+		p.secret = secret
+		p.expiresAt = time.Now().Add(p.ttl)
+	}
+	p.refresh = nil
+	p.mu.Unlock()
+
+	refresh.secret = secret
+	refresh.err = err
+	close(refresh.done)
+}
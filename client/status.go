@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+)
+
+type OtpStatus struct {
+	Verified bool `json:"verified"`
+	Enabled  bool `json:"enabled"`
+	// Stale is set when the status was served from the cache because the OTP
+	// service was unavailable. See WithStaleStatusFallback.
+	Stale bool `json:"-"`
+}
+
+type cachedOtpStatus struct {
+	status    OtpStatus
+	fetchedAt time.Time
+}
+
+// WithStaleStatusFallback makes GetUserOtpStatus return the last successfully
+// fetched status of a user, marked as stale, when the OTP service responds with
+// a server error or cannot be reached. Statuses older than maxStaleness are not
+// served and the original error is returned instead.
+func WithStaleStatusFallback(maxStaleness time.Duration) Option {
+	return func(oc *OtpClient) {
+		oc.statusMaxStaleness = maxStaleness
+	}
+}
+
+// GetUserOtpStatus returns whether the user's OTP is verified and enabled. It
+// reads the same endpoint as GetUserOtp, which also returns the secret, but
+// only decodes the status, so the secret is never handed to the caller.
+func (oc *OtpClient) GetUserOtpStatus(ctx context.Context, userId int, opts ...RequestOption) (OtpStatus, error) {
+	req := http_client.HttpRequest{
+		Url: oc.endpoint(fmt.Sprintf("/users/%d/otp", userId)),
+	}
+
+	resp, err := getRequest[OtpStatus](ctx, oc, "GetUserOtpStatus", req, opts)
+	if err != nil {
+		if cached, ok := oc.staleOtpStatus(userId, err); ok {
+			return cached, nil
+		}
+
+		return OtpStatus{}, err
+	}
+
+	oc.cacheOtpStatus(userId, resp)
+	return resp, nil
+}
+
+func (oc *OtpClient) cacheOtpStatus(userId int, status OtpStatus) {
+	if oc.statusMaxStaleness <= 0 {
+		return
+	}
+
+	oc.mutex().Lock()
+	defer oc.mutex().Unlock()
+
+	if oc.statusCache == nil {
+		oc.statusCache = make(map[int]cachedOtpStatus)
+	}
+
+	oc.statusCache[userId] = cachedOtpStatus{status, time.Now()}
+}
+
+func (oc *OtpClient) staleOtpStatus(userId int, err error) (OtpStatus, bool) {
+	if oc.statusMaxStaleness <= 0 || !isServiceUnavailable(err) {
+		return OtpStatus{}, false
+	}
+
+	oc.mutex().Lock()
+	cached, ok := oc.statusCache[userId]
+	oc.mutex().Unlock()
+
+	if !ok || time.Since(cached.fetchedAt) > oc.statusMaxStaleness {
+		return OtpStatus{}, false
+	}
+
+	cached.status.Stale = true
+	return cached.status, true
+}
+
+// isServiceUnavailable reports whether err was caused by the OTP service
+// being down, rather than by the request itself.
+func isServiceUnavailable(err error) bool {
+	var unknownErr *UnknownError
+	if errors.As(err, &unknownErr) {
+		return unknownErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var dnsErr *DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	return isConnectionError(err)
+}
+
+type GetUsersOtpStatusRequest struct {
+	UserIds []int `json:"user_ids"`
+}
+
+type GetUsersOtpStatusResponse struct {
+	Statuses map[int]OtpStatus `json:"statuses"`
+}
+
+// GetUsersOtpStatus returns the OTP status of several users in a single round
+// trip. Users without OTP are absent from the result. Servers without the
+// bulk endpoint are queried for each user concurrently instead.
+func (oc *OtpClient) GetUsersOtpStatus(ctx context.Context, userIds []int, opts ...RequestOption) (map[int]OtpStatus, error) {
+	if len(userIds) == 0 {
+		return map[int]OtpStatus{}, nil
+	}
+
+	req := http_client.HttpRequestWithBody[GetUsersOtpStatusRequest]{
+		HttpRequest: http_client.HttpRequest{
+			Url: oc.endpoint("/users/otp/status/batch"),
+		},
+		Body: GetUsersOtpStatusRequest{
+			UserIds: userIds,
+		},
+	}
+
+	resp, err := postRequestWithBody[GetUsersOtpStatusRequest, GetUsersOtpStatusResponse](ctx, oc, "GetUsersOtpStatus", req, opts)
+	if err != nil {
+		err = asUnsupportedRoute(err, "bulk otp status")
+		if errors.Is(err, errors.ErrUnsupported) {
+			return oc.getUsersOtpStatusIndividually(ctx, userIds, opts)
+		}
+
+		return nil, err
+	}
+
+	if resp.Statuses == nil {
+		return map[int]OtpStatus{}, nil
+	}
+
+	return resp.Statuses, nil
+}
+
+func (oc *OtpClient) getUsersOtpStatusIndividually(ctx context.Context, userIds []int, opts []RequestOption) (map[int]OtpStatus, error) {
+	statuses := make(map[int]OtpStatus)
+	opts = fanOutRequestOptions(opts, len(userIds))
+
+	var mu sync.Mutex
+	var firstErr error
+	forEachUser(userIds, oc.batchWorkers(), func(userId int) {
+		status, err := oc.GetUserOtpStatus(ctx, userId, opts...)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		var notFoundErr *NotFoundError
+		switch {
+		case errors.As(err, &notFoundErr):
+		case err != nil:
+			if firstErr == nil {
+				firstErr = err
+			}
+		default:
+			statuses[userId] = status
+		}
+	})
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var statusReportHeader = []string{"user_id", "enabled", "verified", "error"}
+
+type statusReportJob struct {
+	rawUserId string
+	userId    int
+	err       error
+}
+
+type statusReportRow struct {
+	rawUserId string
+	status    OtpStatus
+	err       error
+}
+
+// CheckOtpStatusFromReader reads user ids from r, one per line or in the first
+// column of a CSV file, and writes a CSV report of their OTP statuses to out
+// with the columns user_id, enabled, verified and error. A header line without
+// a numeric user id is skipped. Statuses are fetched concurrently and rows are
+// written as they complete, so they are not in the order of the input, and
+// only a bounded number of ids is held in memory at once.
+//
+// Users whose status could not be fetched are reported with the error rather
+// than failing the whole report. An error is only returned if r could not be
+// read, out could not be written, or ctx is done.
+func (oc *OtpClient) CheckOtpStatusFromReader(ctx context.Context, r io.Reader, out io.Writer, opts ...RequestOption) error {
+	callerCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Any number of requests are made at once, see fanOutRequestOptions.
+	opts = append(opts[:len(opts):len(opts)], withoutResponseMetadata())
+
+	jobs := make(chan statusReportJob)
+	rows := make(chan statusReportRow)
+
+	var wg sync.WaitGroup
+	for i := 0; i < oc.batchWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				row := statusReportRow{rawUserId: job.rawUserId, err: job.err}
+				if row.err == nil {
+					row.status, row.err = oc.GetUserOtpStatus(ctx, job.userId, opts...)
+				}
+
+				select {
+				case rows <- row:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- writeStatusReport(out, rows)
+		// Stop reading and fetching if the report can no longer be written.
+		cancel()
+		for range rows {
+		}
+	}()
+
+	readErr := readStatusReportJobs(ctx, r, jobs)
+	close(jobs)
+	wg.Wait()
+	close(rows)
+
+	if err := <-writeErr; err != nil {
+		return err
+	}
+
+	if readErr != nil {
+		return readErr
+	}
+
+	// The whole input may have been read before ctx was done, in which case
+	// the report is missing the rows which were still being fetched.
+	return callerCtx.Err()
+}
+
+func readStatusReportJobs(ctx context.Context, r io.Reader, jobs chan<- statusReportJob) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		rawUserId := strings.TrimSpace(record[0])
+		job := statusReportJob{rawUserId: rawUserId}
+		job.userId, err = strconv.Atoi(rawUserId)
+		if err != nil {
+			if first {
+				continue
+			}
+
+			job.err = fmt.Errorf("invalid user id %q", rawUserId)
+		}
+
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func writeStatusReport(out io.Writer, rows <-chan statusReportRow) error {
+	writer := csv.NewWriter(out)
+	if err := writer.Write(statusReportHeader); err != nil {
+		return err
+	}
+
+	for row := range rows {
+		record := []string{row.rawUserId, "", "", ""}
+		if row.err != nil {
+			record[3] = row.err.Error()
+		} else {
+			record[1] = strconv.FormatBool(row.status.Enabled)
+			record[2] = strconv.FormatBool(row.status.Verified)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		// Flush every row, so that the report streams to out as it is built.
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckOtpStatusFromReader(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/1/otp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": true, "enabled": true}`))
+	})
+	mux.HandleFunc("/users/2/otp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": false, "enabled": false}`))
+	})
+	mux.HandleFunc("/users/3/otp", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Run with -race: the workers must not all fill md at once.
+	var md ResponseMetadata
+	var out strings.Builder
+	input := "user_id,name\n1,alice\n2,bob\n3,carol\nfoo,dave\n"
+	err := New(srv.URL, "secret").CheckOtpStatusFromReader(context.Background(), strings.NewReader(input), &out, WithResponseMetadata(&md))
+	if err != nil {
+		t.Fatalf("CheckOtpStatusFromReader() error = %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("report is not valid csv: %v", err)
+	}
+
+	if !slices.Equal(records[0], statusReportHeader) {
+		t.Errorf("header = %v, want %v", records[0], statusReportHeader)
+	}
+
+	rows := records[1:]
+	slices.SortFunc(rows, func(a, b []string) int { return strings.Compare(a[0], b[0]) })
+
+	want := [][]string{
+		{"1", "true", "true", ""},
+		{"2", "false", "false", ""},
+		{"3", "", "", (&NotFoundError{}).Error()},
+		{"foo", "", "", `invalid user id "foo"`},
+	}
+	if !slices.EqualFunc(rows, want, slices.Equal[[]string]) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestCheckOtpStatusFromReaderCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The input is read in full before the first response arrives.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": true, "enabled": true}`))
+	}))
+	defer srv.Close()
+
+	var out strings.Builder
+	err := New(srv.URL, "secret").CheckOtpStatusFromReader(ctx, strings.NewReader("1\n"), &out)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CheckOtpStatusFromReader() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestGetUsersOtpStatusFallsBackWithoutBulkEndpoint(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/otp/status/batch", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/users/1/otp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": true, "enabled": true}`))
+	})
+	mux.HandleFunc("/users/2/otp", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	statuses, err := New(srv.URL, "secret").GetUsersOtpStatus(context.Background(), []int{1, 2})
+	if err != nil {
+		t.Fatalf("GetUsersOtpStatus() error = %v", err)
+	}
+
+	want := map[int]OtpStatus{1: {Verified: true, Enabled: true}}
+	if len(statuses) != len(want) || statuses[1] != want[1] {
+		t.Errorf("GetUsersOtpStatus() = %v, want %v", statuses, want)
+	}
+}
+
+func TestGetUsersOtpStatusFallbackWithResponseMetadata(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/otp/status/batch", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified": true, "enabled": true}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	userIds := make([]int, 32)
+	for i := range userIds {
+		userIds[i] = i + 1
+	}
+
+	// Run with -race: the per-user requests must not all fill md at once.
+	var md ResponseMetadata
+	statuses, err := New(srv.URL, "secret").GetUsersOtpStatus(context.Background(), userIds, WithResponseMetadata(&md), WithResponseBodyCopy())
+	if err != nil {
+		t.Fatalf("GetUsersOtpStatus() error = %v", err)
+	}
+
+	if len(statuses) != len(userIds) {
+		t.Errorf("GetUsersOtpStatus() = %v, want a status for every user", statuses)
+	}
+}
+
+func TestStaleStatusFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		failure   func() (*http.Response, error)
+		wantStale bool
+	}{
+		{"server error", func() (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(http.StatusServiceUnavailable)
+			return rec.Result(), nil
+		}, true},
+		{"connection refused", func() (*http.Response, error) {
+			return nil, &url.Error{Op: "Get", URL: "http://otp", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+		}, true},
+		{"untrusted certificate", func() (*http.Response, error) {
+			return nil, &url.Error{Op: "Get", URL: "https://otp", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}
+		}, false},
+		{"not found", func() (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(http.StatusNotFound)
+			return rec.Result(), nil
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failing := false
+			doer := DoerFunc(func(req *http.Request) (*http.Response, error) {
+				if failing {
+					return tt.failure()
+				}
+
+				rec := httptest.NewRecorder()
+				rec.Header().Set("Content-Type", "application/json")
+				rec.WriteString(`{"verified": true, "enabled": true}`)
+				return rec.Result(), nil
+			})
+
+			oc := New("http://otp", "secret", WithDoer(doer), WithStaleStatusFallback(time.Minute))
+			if _, err := oc.GetUserOtpStatus(context.Background(), 1); err != nil {
+				t.Fatalf("GetUserOtpStatus() error = %v", err)
+			}
+
+			failing = true
+			status, err := oc.GetUserOtpStatus(context.Background(), 1)
+			if tt.wantStale {
+				if err != nil || !status.Stale || !status.Enabled {
+					t.Errorf("GetUserOtpStatus() = %+v, %v, want the stale status", status, err)
+				}
+			} else if err == nil {
+				t.Errorf("GetUserOtpStatus() = %+v, want an error", status)
+			}
+		})
+	}
+}
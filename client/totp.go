@@ -0,0 +1,138 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits = 6
+
+	defaultOfflineValidationWindow = 1
+)
+
+// WithOfflineValidationWindow sets how many time steps before and after the
+// current one ValidateOtpOffline accepts tokens from, to tolerate clock skew.
+// It defaults to 1.
+func WithOfflineValidationWindow(steps int) Option {
+	return func(oc *OtpClient) {
+		if steps < 0 {
+			oc.setConfigErr("offline validation window must not be negative")
+			return
+		}
+
+		oc.offlineValidationWindow = &steps
+	}
+}
+
+// WithOfflineTotpDigits sets the number of digits of the tokens
+// ValidateOtpOffline accepts, which must be between 6 and 8. It defaults to
+// the 6 digits used by the OTP service.
+func WithOfflineTotpDigits(digits int) Option {
+	return func(oc *OtpClient) {
+		if digits < 6 || digits > 8 {
+			oc.setConfigErr(fmt.Sprintf("offline totp digits must be between 6 and 8, got %d", digits))
+			return
+		}
+
+		oc.offlineTotpDigits = digits
+	}
+}
+
+func decodeTotpSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	return key, nil
+}
+
+func hotpCode(key []byte, counter uint64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation as described in RFC 4226, section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, code%modulo)
+}
+
+func totpCounter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(totpPeriod/time.Second))
+}
+
+// GenerateTotp returns the RFC 6238 token for the base32 encoded secret at
+// time t, using the OTP service's parameters of 6 digits, a 30 second period
+// and SHA1.
+func GenerateTotp(secret string, t time.Time) (string, error) {
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotpCode(key, totpCounter(t), totpDigits), nil
+}
+
+// ValidateOtpOffline checks a token against a previously fetched secret, such
+// as the one returned by GetUserOtp, locally, accepting tokens within the
+// configured window of time steps around t. It is only meant as a fallback
+// while the OTP service is unavailable, and only where policy allows it: it
+// bypasses the server's replay protection, so a token accepted here may be
+// used again.
+func (oc *OtpClient) ValidateOtpOffline(secret, token string, t time.Time) (bool, error) {
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return false, err
+	}
+
+	window := defaultOfflineValidationWindow
+	if oc.offlineValidationWindow != nil {
+		window = *oc.offlineValidationWindow
+	}
+
+	digits := totpDigits
+	if oc.offlineTotpDigits != 0 {
+		digits = oc.offlineTotpDigits
+	}
+
+	return validateTotp(key, token, t, window, digits), nil
+}
+
+// ValidateTokenLocally is ValidateOtpOffline at the current time. The window
+// and digits are configured with WithOfflineValidationWindow and
+// WithOfflineTotpDigits.
+func (oc *OtpClient) ValidateTokenLocally(secret, token string) (bool, error) {
+	return oc.ValidateOtpOffline(secret, token, time.Now())
+}
+
+func validateTotp(key []byte, token string, t time.Time, window, digits int) bool {
+	counter := totpCounter(t)
+	for step := -window; step <= window; step++ {
+		expected := hotpCode(key, counter+uint64(step), digits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateOtpOffline(t *testing.T) {
+	// The SHA1 test vector of RFC 6238, appendix B.
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	at := time.Unix(59, 0)
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		token string
+		t     time.Time
+		want  bool
+	}{
+		{"current step", nil, "287082", at, true},
+		{"previous step within window", nil, "287082", at.Add(30 * time.Second), true},
+		{"previous step outside window", []Option{WithOfflineValidationWindow(0)}, "287082", at.Add(30 * time.Second), false},
+		{"eight digits", []Option{WithOfflineTotpDigits(8)}, "94287082", at, true},
+		{"six digits when eight are configured", []Option{WithOfflineTotpDigits(8)}, "287082", at, false},
+		{"wrong token", nil, "123456", at, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New("https://otp.example.com", "secret", tt.opts...).ValidateOtpOffline(secret, tt.token, tt.t)
+			if err != nil {
+				t.Fatalf("ValidateOtpOffline() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ValidateOtpOffline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTokenLocally(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	oc := New("https://otp.example.com", "secret", WithOfflineTotpDigits(8))
+
+	token, err := GenerateTotp(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GenerateTotp only makes the service's 6 digit tokens.
+	if ok, err := oc.ValidateTokenLocally(secret, token); err != nil || ok {
+		t.Errorf("ValidateTokenLocally() = %v, %v, want a 6 digit token rejected", ok, err)
+	}
+
+	if ok, err := New("https://otp.example.com", "secret").ValidateTokenLocally(secret, token); err != nil || !ok {
+		t.Errorf("ValidateTokenLocally() = %v, %v, want the current token accepted", ok, err)
+	}
+
+	if _, err := oc.ValidateTokenLocally("not base32!", token); err == nil {
+		t.Error("ValidateTokenLocally() accepted a malformed secret")
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer traces the operations of the client without tying it to a tracing
+// library. The otelclient package provides one for OpenTelemetry.
+type Tracer interface {
+	// Start starts a span for the named operation, such as "VerifyOtp",
+	// returning a context carrying it.
+	Start(ctx context.Context, operation string) (context.Context, Span)
+	// Inject propagates the span carried by ctx into the headers of an
+	// outgoing request.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is an operation traced by a Tracer.
+type Span interface {
+	// End ends the span with the status code of the response, which is zero
+	// if none was received, and the error the operation failed with, if any.
+	End(statusCode int, err error)
+}
+
+// LogSampledSpan is a Span which records whether its call was logged, as
+// decided by WithLogSampleRate, e.g. to find the log entries of a trace. The
+// client calls SetLogSampled before End.
+type LogSampledSpan interface {
+	Span
+	SetLogSampled(sampled bool)
+}
+
+// WithTracer traces every call made by the client with tracer.
+func WithTracer(tracer Tracer) Option {
+	return func(oc *OtpClient) {
+		oc.tracer = tracer
+	}
+}
+
+// tracingDoer injects the span of every request's context into its headers.
+// Redirects followed by an http.Client inherit them from the original request.
+type tracingDoer struct {
+	doer   Doer
+	tracer Tracer
+}
+
+func (d *tracingDoer) Do(req *http.Request) (*http.Response, error) {
+	d.tracer.Inject(req.Context(), req.Header)
+	return d.doer.Do(req)
+}
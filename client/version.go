@@ -0,0 +1,7 @@
+package client
+
+import "github.com/osuAkatsuki/otp-service-client-go/internal/http_client"
+
+// Version is the version of this library, sent to the OTP service in the
+// default User-Agent "otp-service-client-go/<version>".
+const Version = http_client.Version
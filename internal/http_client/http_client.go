@@ -1,20 +1,65 @@
 package http_client
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
 )
 
+// Doer sends HTTP requests, like *http.Client does.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
 type HttpRequestWithHeaders interface {
 	AddHeader(key, value string)
+	SetHeader(key, value string)
 }
 
 type HttpRequest struct {
 	Url             string
 	QueryParameters map[string]string
-	Headers         map[string]string
+	// Headers are added to the request, alongside any other values of the
+	// same header.
+	Headers map[string]string
+	// SingularHeaders replace any other values of the same header, including
+	// those from Headers.
+	SingularHeaders map[string]string
+	// MaxContentLength rejects responses advertising a larger Content-Length
+	// before their body is read. Zero means no limit.
+	MaxContentLength int64
+	// MaxBodyBytes fails responses whose body, once decompressed, turns out
+	// to be larger while it is read. Zero means no limit.
+	MaxBodyBytes int64
+	// ExpectContinueThreshold sends bodies larger than this many bytes with
+	// an Expect: 100-continue header. Zero disables it.
+	ExpectContinueThreshold int
+	// ParseNotFoundBody parses the problem of 404 responses into ErrorBody,
+	// which is otherwise left empty for them.
+	ParseNotFoundBody bool
+	// StrictJSON rejects successful responses whose body has fields the
+	// decoded type lacks.
+	StrictJSON bool
+	// AllowEmptyBody decodes successful responses without a body into the
+	// zero value, rather than failing with a ResponseDecodingError.
+	AllowEmptyBody bool
+	// RetainBody keeps the RawBody of responses whose body is decoded, which
+	// are otherwise decoded as they are read without buffering them.
+	RetainBody bool
+	// UserAgent replaces the default UserAgent if set.
+	UserAgent string
+	// SensitiveHeaders carry credentials, and must not be sent to other hosts
+	// when following redirects. See SensitiveHeadersFromContext.
+	SensitiveHeaders []string
 }
 
 func (r *HttpRequest) AddHeader(key, value string) {
@@ -25,6 +70,40 @@ func (r *HttpRequest) AddHeader(key, value string) {
 	r.Headers[key] = value
 }
 
+func (r *HttpRequest) SetHeader(key, value string) {
+	if r.SingularHeaders == nil {
+		r.SingularHeaders = make(map[string]string)
+	}
+
+	r.SingularHeaders[key] = value
+}
+
+func (r *HttpRequest) userAgent() string {
+	if r.UserAgent == "" {
+		return UserAgent
+	}
+
+	return r.UserAgent
+}
+
+type sensitiveHeadersKey struct{}
+
+func (r *HttpRequest) context(ctx context.Context) context.Context {
+	if len(r.SensitiveHeaders) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, sensitiveHeadersKey{}, r.SensitiveHeaders)
+}
+
+// SensitiveHeadersFromContext returns the SensitiveHeaders of the request
+// whose http.Request has the given context, e.g. in an http.Client's
+// CheckRedirect.
+func SensitiveHeadersFromContext(ctx context.Context) []string {
+	headers, _ := ctx.Value(sensitiveHeadersKey{}).([]string)
+	return headers
+}
+
 type HttpRequestWithBody[T any] struct {
 	HttpRequest
 	Body T
@@ -39,6 +118,10 @@ type HttpResponse struct {
 	Headers    map[string][]string
 	HasError   bool
 	ErrorBody  ErrorBody
+	// RawBody is the body of responses which were not decoded, and of
+	// decoded ones only if the request set RetainBody.
+	RawBody []byte
+	TLS     *tls.ConnectionState
 }
 
 type HttpResponseWithBody[T any] struct {
@@ -46,73 +129,119 @@ type HttpResponseWithBody[T any] struct {
 	Body T
 }
 
-const UserAgent = "otp-service-client-go"
+// RequestEncodingError is returned when a request body could not be encoded,
+// before anything was sent to the server.
+type RequestEncodingError struct {
+	Err error
+}
 
-func Get[T any](request HttpRequest) (HttpResponseWithBody[T], error) {
-	req, err := http.NewRequest(http.MethodGet, request.Url, nil)
-	if err != nil {
-		return HttpResponseWithBody[T]{}, err
-	}
+func (e *RequestEncodingError) Error() string {
+	return fmt.Sprintf("failed to encode request body: %s", e.Err)
+}
 
-	q := req.URL.Query()
+func (e *RequestEncodingError) Unwrap() error {
+	return e.Err
+}
 
-	for queryParameter, queryValue := range request.QueryParameters {
-		q.Add(queryParameter, queryValue)
-	}
+var (
+	errEmptyBody    = errors.New("empty body")
+	errTrailingData = errors.New("unexpected data after the body")
+)
 
-	req.URL.RawQuery = q.Encode()
+// ResponseDecodingError is returned when the body of a successful response
+// could not be decoded into the expected type.
+type ResponseDecodingError struct {
+	// Type is the Go type the body was decoded into.
+	Type string
+	Err  error
+}
 
-	for headerKey, headerValue := range request.Headers {
-		req.Header.Add(headerKey, headerValue)
-	}
+func (e *ResponseDecodingError) Error() string {
+	return fmt.Sprintf("failed to decode response body as %s: %s", e.Type, e.Err)
+}
 
-	req.Header.Add("User-Agent", UserAgent)
+func (e *ResponseDecodingError) Unwrap() error {
+	return e.Err
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return HttpResponseWithBody[T]{}, err
-	}
+type ResponseTooLargeError struct {
+	// ContentLength is -1 if the response did not advertise its length.
+	ContentLength int64
+	Limit         int64
+}
 
-	response := HttpResponseWithBody[T]{
-		HttpResponse: HttpResponse{
-			StatusCode: resp.StatusCode,
-			Headers:    resp.Header,
-		},
+func (e *ResponseTooLargeError) Error() string {
+	if e.ContentLength < 0 {
+		return fmt.Sprintf("response exceeds limit of %d bytes", e.Limit)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
-	if err != nil {
-		return response, err
-	}
+	return fmt.Sprintf("response of %d bytes exceeds limit of %d bytes", e.ContentLength, e.Limit)
+}
 
-	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusNotFound {
-		errorJson, err := parseJson[ErrorBody](body)
-		if err != nil {
-			return response, err
-		}
+// limitedBodyReader fails with a ResponseTooLargeError once more than limit
+// bytes were read, rather than silently truncating the body.
+type limitedBodyReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+}
 
-		response.ErrorBody = errorJson
-		response.HasError = true
-	}
+func limitBody(r io.Reader, limit int64) io.Reader {
+	return &limitedBodyReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
 
-	if response.StatusCode == http.StatusNotFound {
-		return response, nil
+func (l *limitedBodyReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n - int(l.read-l.limit), &ResponseTooLargeError{-1, l.limit}
 	}
 
-	jsonBody, err := parseJson[T](body)
-	if err != nil {
-		return response, err
+	return n, err
+}
+
+// UnexpectedContentTypeError is returned when a successful response has a
+// body which is not JSON, e.g. the HTML page of a misconfigured proxy.
+type UnexpectedContentTypeError struct {
+	ContentType string
+	Body        []byte
+}
+
+// maxErrorBodyExcerpt bounds how much of a body is included in error
+// messages.
+const maxErrorBodyExcerpt = 256
+
+func (e *UnexpectedContentTypeError) Error() string {
+	body := e.Body
+	if len(body) > maxErrorBodyExcerpt {
+		body = body[:maxErrorBodyExcerpt]
 	}
-	response.Body = jsonBody
 
-	return response, nil
+	return fmt.Sprintf("unexpected content type %q of response: %s", e.ContentType, body)
 }
 
-func Post[T any](request HttpRequest) (HttpResponseWithBody[T], error) {
-	req, err := http.NewRequest(http.MethodPost, request.Url, nil)
+// Version is the version of the client library.
+const Version = "0.1.0"
+
+// UserAgent is sent by requests without a UserAgent of their own.
+const UserAgent = "otp-service-client-go/" + Version
+
+// doRequest sends a request with the given method and JSON encoded body,
+// which is nil for requests without one, and reads the response, parsing the
+// problem of error responses. The body of successful JSON responses is passed
+// to decode as it is read, unless decode is nil for requests expecting none.
+// The exported functions only encode and decode JSON bodies on top of it.
+// Whichever function sends it, a request with a body carries a JSON
+// Content-Type, and a 204 response is never decoded as it has no body.
+func doRequest(ctx context.Context, client Doer, method string, request HttpRequest, body []byte, decode func(io.Reader) error) (HttpResponse, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(request.context(ctx), method, request.Url, bodyReader)
 	if err != nil {
-		return HttpResponseWithBody[T]{}, err
+		return HttpResponse{}, err
 	}
 
 	q := req.URL.Query()
@@ -127,282 +256,285 @@ func Post[T any](request HttpRequest) (HttpResponseWithBody[T], error) {
 		req.Header.Add(headerKey, headerValue)
 	}
 
-	req.Header.Add("User-Agent", UserAgent)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return HttpResponseWithBody[T]{}, err
+	for headerKey, headerValue := range request.SingularHeaders {
+		req.Header.Set(headerKey, headerValue)
 	}
 
-	response := HttpResponseWithBody[T]{
-		HttpResponse: HttpResponse{
-			StatusCode: resp.StatusCode,
-			Headers:    resp.Header,
-		},
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		if request.ExpectContinueThreshold > 0 && len(body) > request.ExpectContinueThreshold {
+			req.Header.Set("Expect", "100-continue")
+		}
 	}
+	req.Header.Set("User-Agent", request.userAgent())
 
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
+	resp, err := client.Do(req)
 	if err != nil {
-		return response, err
+		return HttpResponse{}, err
 	}
+	defer resp.Body.Close()
 
-	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusNotFound {
-		errorJson, err := parseJson[ErrorBody](body)
-		if err != nil {
-			return response, err
-		}
-
-		response.ErrorBody = errorJson
-		response.HasError = true
+	response := HttpResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		TLS:        resp.TLS,
 	}
 
-	if response.StatusCode == http.StatusNoContent || response.StatusCode == http.StatusNotFound {
-		return response, nil
+	if request.MaxContentLength > 0 && resp.ContentLength > request.MaxContentLength {
+		return response, &ResponseTooLargeError{resp.ContentLength, request.MaxContentLength}
 	}
 
-	jsonBody, err := parseJson[T](body)
+	responseReader, err := decompressBody(resp)
 	if err != nil {
 		return response, err
 	}
-	response.Body = jsonBody
-
-	return response, nil
-}
 
-func PostWithBody[T any, T1 any](request HttpRequestWithBody[T]) (HttpResponseWithBody[T1], error) {
-	byteData, err := json.Marshal(request.Body)
-	if err != nil {
-		return HttpResponseWithBody[T1]{}, err
+	// Limit the decompressed body, which may be far larger than the one sent.
+	if request.MaxBodyBytes > 0 {
+		responseReader = limitBody(responseReader, request.MaxBodyBytes)
 	}
 
-	byteReader := bytes.NewReader(byteData)
+	hasBody := resp.StatusCode >= 200 && resp.StatusCode <= 299 && resp.StatusCode != http.StatusNoContent
+	contentType := resp.Header.Get("Content-Type")
+	if decode != nil && hasBody && isJsonContentType(contentType) {
+		return response, decodeStream(&response, request, responseReader, decode)
+	}
 
-	req, err := http.NewRequest(http.MethodPost, request.Url, byteReader)
+	responseBody, err := io.ReadAll(responseReader)
 	if err != nil {
-		return HttpResponseWithBody[T1]{}, err
+		return response, err
 	}
+	response.RawBody = responseBody
 
-	q := req.URL.Query()
-
-	for queryParameter, queryValue := range request.QueryParameters {
-		q.Add(queryParameter, queryValue)
+	if decode != nil && hasBody && (len(responseBody) > 0 || !request.AllowEmptyBody) {
+		return response, &UnexpectedContentTypeError{contentType, responseBody}
 	}
 
-	req.URL.RawQuery = q.Encode()
-
-	for headerKey, headerValue := range request.Headers {
-		req.Header.Add(headerKey, headerValue)
+	if response.StatusCode == http.StatusNotFound && request.ParseNotFoundBody {
+		// Bodies which are not a problem are ignored, as 404s often lack one.
+		response.ErrorBody, _ = parseJson[ErrorBody](responseBody)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("User-Agent", UserAgent)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return HttpResponseWithBody[T1]{}, err
+	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusNotFound {
+		response.ErrorBody = parseErrorBody(responseBody)
+		response.HasError = true
 	}
 
-	response := HttpResponseWithBody[T1]{
-		HttpResponse: HttpResponse{
-			StatusCode: resp.StatusCode,
-			Headers:    resp.Header,
-		},
-	}
+	return response, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
-	if err != nil {
-		return response, err
+// decompressBody returns a reader of the decompressed body of a gzip encoded
+// response. The transport only decompresses responses itself if it requested
+// the encoding, rather than a custom transport or the caller.
+func decompressBody(resp *http.Response) (io.Reader, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
 	}
 
-	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusNotFound {
-		errorJson, err := parseJson[ErrorBody](body)
-		if err != nil {
-			return response, err
-		}
-
-		response.ErrorBody = errorJson
-		response.HasError = true
+	// Responses without a body have nothing to decompress, even if servers
+	// label them with the encoding.
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified || resp.ContentLength == 0 {
+		return resp.Body, nil
 	}
 
-	if response.StatusCode == http.StatusNoContent || response.StatusCode == http.StatusNotFound {
-		return response, nil
+	body := bufio.NewReader(resp.Body)
+	if _, err := body.Peek(1); err == io.EOF {
+		return body, nil
 	}
 
-	jsonBody, err := parseJson[T1](body)
+	reader, err := gzip.NewReader(body)
 	if err != nil {
-		return response, err
+		return nil, err
 	}
-	response.Body = jsonBody
 
-	return response, nil
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	return reader, nil
 }
 
-func PostWithNoContent(request HttpRequest) (HttpResponse, error) {
-	req, err := http.NewRequest(http.MethodPost, request.Url, nil)
+func encodeBody[T any](body T) ([]byte, error) {
+	byteData, err := json.Marshal(body)
 	if err != nil {
-		return HttpResponse{}, err
+		return nil, &RequestEncodingError{err}
 	}
 
-	q := req.URL.Query()
-
-	for queryParameter, queryValue := range request.QueryParameters {
-		q.Add(queryParameter, queryValue)
-	}
-
-	req.URL.RawQuery = q.Encode()
+	return byteData, nil
+}
 
-	for headerKey, headerValue := range request.Headers {
-		req.Header.Add(headerKey, headerValue)
+// isJsonContentType reports whether a body of the given content type can be
+// decoded as JSON. Bodies without a content type are assumed to be JSON.
+func isJsonContentType(contentType string) bool {
+	if contentType == "" {
+		return true
 	}
 
-	req.Header.Add("User-Agent", UserAgent)
-
-	resp, err := http.DefaultClient.Do(req)
+	mediaType, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		return HttpResponse{}, err
+		return false
 	}
 
-	response := HttpResponse{
-		StatusCode: resp.StatusCode,
-		Headers:    resp.Header,
-	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
 
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
-	if err != nil {
-		return response, err
+// decodeStream passes the body of a successful response to decode as it is
+// read, retaining a copy in RawBody if the request asks for it.
+func decodeStream(response *HttpResponse, request HttpRequest, body io.Reader, decode func(io.Reader) error) error {
+	var rawBody bytes.Buffer
+	if request.RetainBody {
+		body = io.TeeReader(body, &rawBody)
 	}
 
-	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusNotFound {
-		errorJson, err := parseJson[ErrorBody](body)
-		if err != nil {
-			return response, err
-		}
+	if err := decode(body); err != nil {
+		return err
+	}
 
-		response.ErrorBody = errorJson
-		response.HasError = true
+	// Read whatever the decoder left, so that the connection can be reused.
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
 	}
 
-	if response.StatusCode == http.StatusNotFound {
-		return response, nil
+	if request.RetainBody {
+		response.RawBody = rawBody.Bytes()
 	}
 
-	return response, nil
+	return nil
 }
 
-func PostWithBodyWithNoContent[T any](request HttpRequestWithBody[T]) (HttpResponse, error) {
-	byteData, err := json.Marshal(request.Body)
-	if err != nil {
-		return HttpResponse{}, err
-	}
-
-	byteReader := bytes.NewReader(byteData)
+// jsonDecoder returns a decode function for doRequest which decodes the body
+// into v, rejecting unknown fields for requests with StrictJSON. Like
+// json.Unmarshal, it rejects anything but whitespace after the value.
+func jsonDecoder[T any](request HttpRequest, v *T) func(io.Reader) error {
+	return func(r io.Reader) error {
+		decoder := json.NewDecoder(r)
+		if request.StrictJSON {
+			decoder.DisallowUnknownFields()
+		}
 
-	req, err := http.NewRequest(http.MethodPost, request.Url, byteReader)
-	if err != nil {
-		return HttpResponse{}, err
-	}
+		err := decoder.Decode(v)
+		if err == io.EOF && request.AllowEmptyBody {
+			return nil
+		} else if err == io.EOF {
+			err = errEmptyBody
+		} else if err == nil {
+			if _, err = decoder.Token(); err == io.EOF {
+				return nil
+			} else if err == nil {
+				err = errTrailingData
+			}
+		}
 
-	q := req.URL.Query()
+		// Exceeding the size limit is not a problem of the body's encoding.
+		var tooLargeErr *ResponseTooLargeError
+		if errors.As(err, &tooLargeErr) {
+			return err
+		}
 
-	for queryParameter, queryValue := range request.QueryParameters {
-		q.Add(queryParameter, queryValue)
+		return &ResponseDecodingError{fmt.Sprintf("%T", *v), err}
 	}
+}
 
-	req.URL.RawQuery = q.Encode()
+// requestWithBody is doRequest for requests whose successful responses have
+// a JSON body, which is decoded into the result.
+func requestWithBody[T any](ctx context.Context, client Doer, method string, request HttpRequest, body []byte) (HttpResponseWithBody[T], error) {
+	var result HttpResponseWithBody[T]
 
-	for headerKey, headerValue := range request.Headers {
-		req.Header.Add(headerKey, headerValue)
-	}
+	response, err := doRequest(ctx, client, method, request, body, jsonDecoder(request, &result.Body))
+	result.HttpResponse = response
+	return result, err
+}
 
-	req.Header.Add("User-Agent", UserAgent)
+func Get[T any](ctx context.Context, client Doer, request HttpRequest) (HttpResponseWithBody[T], error) {
+	return requestWithBody[T](ctx, client, http.MethodGet, request, nil)
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return HttpResponse{}, err
-	}
+func GetWithNoContent(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+	return doRequest(ctx, client, http.MethodGet, request, nil, nil)
+}
 
-	response := HttpResponse{
-		StatusCode: resp.StatusCode,
-		Headers:    resp.Header,
-	}
+func Post[T any](ctx context.Context, client Doer, request HttpRequest) (HttpResponseWithBody[T], error) {
+	return requestWithBody[T](ctx, client, http.MethodPost, request, nil)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
+func PostWithBody[T any, T1 any](ctx context.Context, client Doer, request HttpRequestWithBody[T]) (HttpResponseWithBody[T1], error) {
+	body, err := encodeBody(request.Body)
 	if err != nil {
-		return response, err
-	}
-
-	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusNotFound {
-		errorJson, err := parseJson[ErrorBody](body)
-		if err != nil {
-			return response, err
-		}
-
-		response.ErrorBody = errorJson
-		response.HasError = true
+		return HttpResponseWithBody[T1]{}, err
 	}
 
-	if response.StatusCode == http.StatusNotFound {
-		return response, nil
-	}
+	return requestWithBody[T1](ctx, client, http.MethodPost, request.HttpRequest, body)
+}
 
-	return response, nil
+func PostWithNoContent(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+	return doRequest(ctx, client, http.MethodPost, request, nil, nil)
 }
 
-func DeleteWithNoContent(request HttpRequest) (HttpResponse, error) {
-	req, err := http.NewRequest(http.MethodDelete, request.Url, nil)
+func PostWithBodyWithNoContent[T any](ctx context.Context, client Doer, request HttpRequestWithBody[T]) (HttpResponse, error) {
+	body, err := encodeBody(request.Body)
 	if err != nil {
 		return HttpResponse{}, err
 	}
 
-	q := req.URL.Query()
+	return doRequest(ctx, client, http.MethodPost, request.HttpRequest, body, nil)
+}
 
-	for queryParameter, queryValue := range request.QueryParameters {
-		q.Add(queryParameter, queryValue)
+func Put[T any](ctx context.Context, client Doer, request HttpRequest) (HttpResponseWithBody[T], error) {
+	return requestWithBody[T](ctx, client, http.MethodPut, request, nil)
+}
+
+func PutWithBody[T any, T1 any](ctx context.Context, client Doer, request HttpRequestWithBody[T]) (HttpResponseWithBody[T1], error) {
+	body, err := encodeBody(request.Body)
+	if err != nil {
+		return HttpResponseWithBody[T1]{}, err
 	}
 
-	req.URL.RawQuery = q.Encode()
+	return requestWithBody[T1](ctx, client, http.MethodPut, request.HttpRequest, body)
+}
 
-	for headerKey, headerValue := range request.Headers {
-		req.Header.Add(headerKey, headerValue)
+func PatchWithBody[T any, T1 any](ctx context.Context, client Doer, request HttpRequestWithBody[T]) (HttpResponseWithBody[T1], error) {
+	body, err := encodeBody(request.Body)
+	if err != nil {
+		return HttpResponseWithBody[T1]{}, err
 	}
 
-	req.Header.Add("User-Agent", UserAgent)
+	return requestWithBody[T1](ctx, client, http.MethodPatch, request.HttpRequest, body)
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func PatchWithBodyWithNoContent[T any](ctx context.Context, client Doer, request HttpRequestWithBody[T]) (HttpResponse, error) {
+	body, err := encodeBody(request.Body)
 	if err != nil {
 		return HttpResponse{}, err
 	}
 
-	response := HttpResponse{
-		StatusCode: resp.StatusCode,
-		Headers:    resp.Header,
-	}
+	return doRequest(ctx, client, http.MethodPatch, request.HttpRequest, body, nil)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
+func DeleteWithNoContent(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+	return doRequest(ctx, client, http.MethodDelete, request, nil, nil)
+}
+
+func DeleteWithBodyWithNoContent[T any](ctx context.Context, client Doer, request HttpRequestWithBody[T]) (HttpResponse, error) {
+	body, err := encodeBody(request.Body)
 	if err != nil {
-		return response, err
+		return HttpResponse{}, err
 	}
 
-	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusNotFound {
-		errorJson, err := parseJson[ErrorBody](body)
-		if err != nil {
-			return response, err
-		}
+	return doRequest(ctx, client, http.MethodDelete, request.HttpRequest, body, nil)
+}
 
-		response.ErrorBody = errorJson
-		response.HasError = true
+// parseErrorBody parses the problem of an error response, falling back to the
+// raw body for responses which are not JSON, such as the HTML error pages of
+// proxies in front of the OTP service.
+func parseErrorBody(body []byte) ErrorBody {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ErrorBody{}
 	}
 
-	if response.StatusCode == http.StatusNotFound {
-		return response, nil
+	errorBody, err := parseJson[ErrorBody](body)
+	if err != nil {
+		return ErrorBody{Problem: strings.TrimSpace(string(body))}
 	}
 
-	return response, nil
+	return errorBody
 }
 
 func parseJson[T any](s []byte) (T, error) {
@@ -0,0 +1,387 @@
+package http_client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testBody struct {
+	Verified bool `json:"verified"`
+}
+
+// serve starts a server answering every request with the given status,
+// headers and body.
+func serve(t *testing.T, status int, headers map[string]string, body string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+var jsonHeaders = map[string]string{"Content-Type": "application/json"}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		body    string
+		want    testBody
+		wantErr bool
+	}{
+		{"object", false, `{"verified": true}`, testBody{Verified: true}, false},
+		{"trailing whitespace", false, "{\"verified\": true}\n\t ", testBody{Verified: true}, false},
+		{"unknown field", false, `{"verified": true, "extra": 1}`, testBody{Verified: true}, false},
+		{"trailing data", false, `{"verified": true} {"garbage"`, testBody{}, true},
+		{"trailing data strict", true, `{"verified": true} {"garbage"`, testBody{}, true},
+		{"unknown field strict", true, `{"verified": true, "extra": 1}`, testBody{}, true},
+		{"empty", false, ``, testBody{}, true},
+		{"malformed", false, `{"verified": tru`, testBody{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, http.StatusOK, jsonHeaders, tt.body)
+
+			resp, err := Get[testBody](context.Background(), http.DefaultClient, HttpRequest{Url: srv.URL, StrictJSON: tt.strict})
+			if tt.wantErr {
+				var decodingErr *ResponseDecodingError
+				if !errors.As(err, &decodingErr) {
+					t.Fatalf("Get() error = %v, want a ResponseDecodingError", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			if resp.Body != tt.want {
+				t.Errorf("Get() body = %+v, want %+v", resp.Body, tt.want)
+			}
+		})
+	}
+}
+
+func gzipped(t *testing.T, s string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestGzipResponses(t *testing.T) {
+	headers := map[string]string{"Content-Type": "application/json", "Content-Encoding": "gzip"}
+
+	t.Run("json body", func(t *testing.T) {
+		srv := serve(t, http.StatusOK, headers, gzipped(t, `{"verified": true}`))
+
+		// A transport which does not request gzip does not decompress it.
+		client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+		resp, err := Get[testBody](context.Background(), client, HttpRequest{Url: srv.URL})
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+
+		if !resp.Body.Verified {
+			t.Errorf("Get() body = %+v, want verified", resp.Body)
+		}
+	})
+
+	t.Run("error body", func(t *testing.T) {
+		srv := serve(t, http.StatusBadRequest, headers, gzipped(t, `{"problem": "invalid token"}`))
+
+		resp, err := PostWithNoContent(context.Background(), http.DefaultClient, HttpRequest{Url: srv.URL})
+		if err != nil {
+			t.Fatalf("PostWithNoContent() error = %v", err)
+		}
+
+		if resp.ErrorBody.Problem != "invalid token" {
+			t.Errorf("PostWithNoContent() problem = %q, want %q", resp.ErrorBody.Problem, "invalid token")
+		}
+	})
+
+	for _, status := range []int{http.StatusNoContent, http.StatusOK} {
+		t.Run(fmt.Sprintf("empty %d", status), func(t *testing.T) {
+			srv := serve(t, status, headers, "")
+
+			resp, err := PostWithNoContent(context.Background(), http.DefaultClient, HttpRequest{Url: srv.URL})
+			if err != nil {
+				t.Fatalf("PostWithNoContent() error = %v", err)
+			}
+
+			if resp.StatusCode != status || resp.HasError {
+				t.Errorf("PostWithNoContent() = %d, has error %v, want %d without", resp.StatusCode, resp.HasError, status)
+			}
+		})
+	}
+}
+
+// sendFuncs sends a request through every exported function, so that tests can
+// check they behave alike.
+var sendFuncs = []struct {
+	name    string
+	method  string
+	hasBody bool
+	send    func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error)
+}{
+	{"Get", http.MethodGet, false, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		resp, err := Get[testBody](ctx, client, request)
+		return resp.HttpResponse, err
+	}},
+	{"GetWithNoContent", http.MethodGet, false, GetWithNoContent},
+	{"Post", http.MethodPost, false, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		resp, err := Post[testBody](ctx, client, request)
+		return resp.HttpResponse, err
+	}},
+	{"PostWithBody", http.MethodPost, true, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		resp, err := PostWithBody[testBody, testBody](ctx, client, HttpRequestWithBody[testBody]{request, testBody{true}})
+		return resp.HttpResponse, err
+	}},
+	{"PostWithNoContent", http.MethodPost, false, PostWithNoContent},
+	{"PostWithBodyWithNoContent", http.MethodPost, true, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		return PostWithBodyWithNoContent(ctx, client, HttpRequestWithBody[testBody]{request, testBody{true}})
+	}},
+	{"Put", http.MethodPut, false, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		resp, err := Put[testBody](ctx, client, request)
+		return resp.HttpResponse, err
+	}},
+	{"PutWithBody", http.MethodPut, true, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		resp, err := PutWithBody[testBody, testBody](ctx, client, HttpRequestWithBody[testBody]{request, testBody{true}})
+		return resp.HttpResponse, err
+	}},
+	{"PatchWithBody", http.MethodPatch, true, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		resp, err := PatchWithBody[testBody, testBody](ctx, client, HttpRequestWithBody[testBody]{request, testBody{true}})
+		return resp.HttpResponse, err
+	}},
+	{"PatchWithBodyWithNoContent", http.MethodPatch, true, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		return PatchWithBodyWithNoContent(ctx, client, HttpRequestWithBody[testBody]{request, testBody{true}})
+	}},
+	{"DeleteWithNoContent", http.MethodDelete, false, DeleteWithNoContent},
+	{"DeleteWithBodyWithNoContent", http.MethodDelete, true, func(ctx context.Context, client Doer, request HttpRequest) (HttpResponse, error) {
+		return DeleteWithBodyWithNoContent(ctx, client, HttpRequestWithBody[testBody]{request, testBody{true}})
+	}},
+}
+
+func TestRequests(t *testing.T) {
+	for _, tt := range sendFuncs {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.method {
+					t.Errorf("method = %s, want %s", r.Method, tt.method)
+				}
+
+				if got := r.URL.Query().Get("consume"); got != "false" {
+					t.Errorf("consume query parameter = %q, want %q", got, "false")
+				}
+
+				if got := r.Header.Get("X-Added"); got != "added" {
+					t.Errorf("X-Added = %q, want %q", got, "added")
+				}
+
+				if got := r.Header.Values("X-Singular"); len(got) != 1 || got[0] != "singular" {
+					t.Errorf("X-Singular = %q, want %q", got, "singular")
+				}
+
+				if got := r.Header.Get("User-Agent"); got != UserAgent {
+					t.Errorf("User-Agent = %q, want %q", got, UserAgent)
+				}
+
+				wantContentType := ""
+				if tt.hasBody {
+					wantContentType = "application/json"
+				}
+
+				if got := r.Header.Get("Content-Type"); got != wantContentType {
+					t.Errorf("Content-Type = %q, want %q", got, wantContentType)
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			request := HttpRequest{
+				Url:             srv.URL + "?consume=false",
+				Headers:         map[string]string{"X-Added": "added", "X-Singular": "added"},
+				SingularHeaders: map[string]string{"X-Singular": "singular"},
+			}
+
+			// 204 responses have no body to decode, whichever function is used.
+			resp, err := tt.send(context.Background(), http.DefaultClient, request)
+			if err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+
+			if resp.StatusCode != http.StatusNoContent || resp.HasError {
+				t.Errorf("%s() = %d, has error %v, want 204 without", tt.name, resp.StatusCode, resp.HasError)
+			}
+		})
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	for _, tt := range sendFuncs {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, http.StatusConflict, jsonHeaders, `{"problem": "otp already verified"}`)
+
+			resp, err := tt.send(context.Background(), http.DefaultClient, HttpRequest{Url: srv.URL})
+			if err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+
+			if !resp.HasError || resp.ErrorBody.Problem != "otp already verified" {
+				t.Errorf("%s() = has error %v, problem %q, want the conflict's problem", tt.name, resp.HasError, resp.ErrorBody.Problem)
+			}
+		})
+	}
+}
+
+func TestNotFoundResponses(t *testing.T) {
+	for _, tt := range sendFuncs {
+		for _, parse := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s parse %v", tt.name, parse), func(t *testing.T) {
+				srv := serve(t, http.StatusNotFound, jsonHeaders, `{"problem": "otp not enabled"}`)
+
+				resp, err := tt.send(context.Background(), http.DefaultClient, HttpRequest{Url: srv.URL, ParseNotFoundBody: parse})
+				if err != nil {
+					t.Fatalf("%s() error = %v", tt.name, err)
+				}
+
+				wantProblem := ""
+				if parse {
+					wantProblem = "otp not enabled"
+				}
+
+				// 404s are left to callers to report, rather than being errors.
+				if resp.StatusCode != http.StatusNotFound || resp.HasError || resp.ErrorBody.Problem != wantProblem {
+					t.Errorf("%s() = %d, has error %v, problem %q, want 404 without error, problem %q",
+						tt.name, resp.StatusCode, resp.HasError, resp.ErrorBody.Problem, wantProblem)
+				}
+			})
+		}
+	}
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantBody    testBody
+		wantProblem string
+	}{
+		{"200 with body", http.StatusOK, `{"verified": true}`, testBody{Verified: true}, ""},
+		{"204", http.StatusNoContent, ``, testBody{}, ""},
+		{"4xx", http.StatusUnprocessableEntity, `{"problem": "unknown enforcement flag"}`, testBody{}, "unknown enforcement flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var sent testBody
+				if err := json.NewDecoder(r.Body).Decode(&sent); err != nil || !sent.Verified {
+					t.Errorf("request body = %+v, %v, want it encoded", sent, err)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			request := HttpRequestWithBody[testBody]{HttpRequest{Url: srv.URL}, testBody{true}}
+			wantError := tt.wantProblem != ""
+
+			resp, err := PatchWithBody[testBody, testBody](context.Background(), http.DefaultClient, request)
+			if err != nil {
+				t.Fatalf("PatchWithBody() error = %v", err)
+			}
+
+			if resp.StatusCode != tt.status || resp.HasError != wantError || resp.ErrorBody.Problem != tt.wantProblem || resp.Body != tt.wantBody {
+				t.Errorf("PatchWithBody() = %d, has error %v, problem %q, body %+v, want %d, has error %v, problem %q, body %+v",
+					resp.StatusCode, resp.HasError, resp.ErrorBody.Problem, resp.Body, tt.status, wantError, tt.wantProblem, tt.wantBody)
+			}
+
+			noContentResp, err := PatchWithBodyWithNoContent(context.Background(), http.DefaultClient, request)
+			if err != nil {
+				t.Fatalf("PatchWithBodyWithNoContent() error = %v", err)
+			}
+
+			if noContentResp.StatusCode != tt.status || noContentResp.HasError != wantError || noContentResp.ErrorBody.Problem != tt.wantProblem {
+				t.Errorf("PatchWithBodyWithNoContent() = %d, has error %v, problem %q, want %d, has error %v, problem %q",
+					noContentResp.StatusCode, noContentResp.HasError, noContentResp.ErrorBody.Problem, tt.status, wantError, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestEmptyErrorBodies(t *testing.T) {
+	for _, body := range []string{"", " \n\t"} {
+		t.Run(fmt.Sprintf("%q", body), func(t *testing.T) {
+			srv := serve(t, http.StatusInternalServerError, jsonHeaders, body)
+
+			resp, err := Post[testBody](context.Background(), http.DefaultClient, HttpRequest{Url: srv.URL})
+			if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+
+			if resp.StatusCode != http.StatusInternalServerError || !resp.HasError || resp.ErrorBody.Problem != "" {
+				t.Errorf("Post() = %d, has error %v, problem %q, want 500 with an empty problem", resp.StatusCode, resp.HasError, resp.ErrorBody.Problem)
+			}
+		})
+	}
+}
+
+func TestAllowEmptyBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		want    testBody
+	}{
+		{"empty json", jsonHeaders, ``, testBody{}},
+		{"empty without content type", nil, ``, testBody{}},
+		{"empty plain text", map[string]string{"Content-Type": "text/plain"}, ``, testBody{}},
+		{"object", jsonHeaders, `{"verified": true}`, testBody{Verified: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, http.StatusOK, tt.headers, tt.body)
+
+			resp, err := Post[testBody](context.Background(), http.DefaultClient, HttpRequest{Url: srv.URL, AllowEmptyBody: true})
+			if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+
+			if resp.Body != tt.want {
+				t.Errorf("Post() body = %+v, want %+v", resp.Body, tt.want)
+			}
+		})
+	}
+}